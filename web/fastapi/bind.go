@@ -0,0 +1,206 @@
+package fastapi
+
+import (
+	"encoding"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// parameterLocations are the struct tags that bind an input field from
+// somewhere other than the request body, in binding order.
+var parameterLocations = []string{"path", "query", "header", "cookie", "form"}
+
+// bindParams fills the parameter fields of the input struct and reports
+// missing or malformed values as a *ValidationError.
+func bindParams(c *gin.Context, inputVal reflect.Value, pathParams map[string]string) error {
+	lookups := map[string]func(name string) []string{
+		"path": func(name string) []string {
+			if value, present := pathParams[name]; present {
+				return []string{value}
+			}
+			return nil
+		},
+		"query": func(name string) []string {
+			return c.Request.URL.Query()[name]
+		},
+		"header": func(name string) []string {
+			return c.Request.Header.Values(name)
+		},
+		"cookie": func(name string) []string {
+			value, err := c.Cookie(name)
+			if err != nil {
+				return nil
+			}
+			return []string{value}
+		},
+		"form": func(name string) []string {
+			return c.Request.PostForm[name]
+		},
+	}
+
+	verr := &ValidationError{}
+	for _, in := range parameterLocations {
+		lookup := lookups[in]
+		eachTaggedField(inputVal, in, func(name string, field reflect.Value, structField reflect.StructField) error {
+			required := parseConstraints(structField.Tag).required
+			if isFileField(field.Type()) {
+				files := formFiles(c, name)
+				switch {
+				case len(files) == 0 && required:
+					verr.add(in, name, "field required")
+				case len(files) == 0:
+				case field.Type() == fileHeaderType:
+					field.Set(reflect.ValueOf(files[0]))
+				default:
+					field.Set(reflect.ValueOf(files))
+				}
+				return nil
+			}
+			values := lookup(name)
+			if in == "header" && isListParam(field.Type()) {
+				// Only list headers are comma separated, others such as
+				// User-Agent may contain commas.
+				values = splitList(strings.Join(values, ","))
+			}
+			if len(values) == 0 {
+				defaultValue, present := structField.Tag.Lookup("default")
+				if required || !present {
+					if required || !isOptionalParam(field.Type()) {
+						verr.add(in, name, "field required")
+					}
+					return nil
+				}
+				values = defaultStrings(field.Type(), defaultValue)
+			}
+			if err := setFromStrings(field, values); err != nil {
+				verr.add(in, name, "invalid value: "+err.Error())
+			}
+			return nil
+		})
+	}
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// isOptionalParam reports whether a parameter field may be absent from the
+// request: pointers stay nil and slices stay empty, everything else needs a
+// value or a default tag.
+func isOptionalParam(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.Ptr || isListParam(fieldType)
+}
+
+func isListParam(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.Slice && !reflect.PtrTo(fieldType).Implements(textUnmarshalerType)
+}
+
+func defaultStrings(fieldType reflect.Type, defaultValue string) []string {
+	if isListParam(fieldType) {
+		return splitList(defaultValue)
+	}
+	return []string{defaultValue}
+}
+
+func splitList(value string) []string {
+	parts := strings.Split(value, ",")
+	values := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// eachTaggedField calls fn for every field of the struct carrying the given
+// tag, descending into embedded structs.
+func eachTaggedField(structVal reflect.Value, tag string, fn func(name string, field reflect.Value, structField reflect.StructField) error) error {
+	if structVal.Kind() != reflect.Struct {
+		return nil
+	}
+	structType := structVal.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if name, present := field.Tag.Lookup(tag); present {
+			if err := fn(name, structVal.Field(i), field); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := eachTaggedField(structVal.Field(i), tag, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func setFromStrings(field reflect.Value, raw []string) error {
+	if !isListParam(field.Type()) {
+		return setFromString(field, raw[0])
+	}
+	slice := reflect.MakeSlice(field.Type(), len(raw), len(raw))
+	for i, value := range raw {
+		if err := setFromString(slice.Index(i), value); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+func setFromString(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.Ptr {
+		value := reflect.New(field.Type().Elem())
+		if err := setFromString(value.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	}
+	if reflect.PtrTo(field.Type()).Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(value)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
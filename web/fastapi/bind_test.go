@@ -0,0 +1,167 @@
+package fastapi_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"testing"
+	"web/fastapi"
+	"web/fastapi/fastapitest"
+)
+
+type bindInput struct {
+	ID        int      `path:"id"`
+	Limit     int      `query:"limit" default:"20"`
+	Tags      []string `query:"tag"`
+	Verbose   *bool    `query:"verbose"`
+	UserAgent string   `header:"User-Agent" default:""`
+	Accepts   []string `header:"X-Accept-Tags"`
+	Session   string   `cookie:"session" default:""`
+}
+
+type bindOutput struct {
+	ID        int      `json:"id"`
+	Limit     int      `json:"limit"`
+	Tags      []string `json:"tags"`
+	Verbose   *bool    `json:"verbose"`
+	UserAgent string   `json:"user_agent"`
+	Accepts   []string `json:"accepts"`
+	Session   string   `json:"session"`
+}
+
+func bindRouter() *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodGet, "/items/{id}", func(c *gin.Context, in bindInput) (bindOutput, error) {
+		return bindOutput(in), nil
+	})
+	return r
+}
+
+func TestBindParams(t *testing.T) {
+	verbose := true
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+		want   bindOutput
+	}{
+		{
+			name:   "defaults",
+			target: "/items/7",
+			want:   bindOutput{ID: 7, Limit: 20},
+		},
+		{
+			name:   "query",
+			target: "/items/7?limit=5&tag=a&tag=b&verbose=true",
+			want:   bindOutput{ID: 7, Limit: 5, Tags: []string{"a", "b"}, Verbose: &verbose},
+		},
+		{
+			name:   "scalar header keeps commas",
+			target: "/items/7",
+			header: http.Header{"User-Agent": {"Mozilla/5.0 (X11, Linux) Foo"}},
+			want:   bindOutput{ID: 7, Limit: 20, UserAgent: "Mozilla/5.0 (X11, Linux) Foo"},
+		},
+		{
+			name:   "list header is split",
+			target: "/items/7",
+			header: http.Header{"X-Accept-Tags": {"a, b", "c"}},
+			want:   bindOutput{ID: 7, Limit: 20, Accepts: []string{"a", "b", "c"}},
+		},
+		{
+			name:   "cookie",
+			target: "/items/7",
+			header: http.Header{"Cookie": {"session=abc"}},
+			want:   bindOutput{ID: 7, Limit: 20, Session: "abc"},
+		},
+	}
+	r := bindRouter()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodGet, test.target, "", test.header)
+			expectStatus(t, recorder, http.StatusOK)
+			var got bindOutput
+			decode(t, recorder, &got)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("bound %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBindParamsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   fastapi.FieldError
+	}{
+		{"malformed path", "/items/x", fastapi.FieldError{In: "path", Path: "id"}},
+		{"malformed query", "/items/7?limit=many", fastapi.FieldError{In: "query", Path: "limit"}},
+		{"malformed pointer", "/items/7?verbose=perhaps", fastapi.FieldError{In: "query", Path: "verbose"}},
+	}
+	r := bindRouter()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodGet, test.target, "", nil)
+			expectStatus(t, recorder, http.StatusUnprocessableEntity)
+			details, _ := problem(t, recorder).Details.([]interface{})
+			if len(details) != 1 {
+				t.Fatalf("details %v, want one field error", details)
+			}
+			fieldErr := details[0].(map[string]interface{})
+			if fieldErr["in"] != test.want.In || fieldErr["path"] != test.want.Path {
+				t.Errorf("field error %v, want %s %s", fieldErr, test.want.In, test.want.Path)
+			}
+		})
+	}
+}
+
+func TestBindWithClient(t *testing.T) {
+	client := fastapitest.NewClient(t, bindRouter())
+	got := fastapitest.MustCall[bindOutput](client, http.MethodGet, "/items/{id}", bindInput{
+		ID:        3,
+		Limit:     2,
+		Tags:      []string{"x"},
+		UserAgent: "agent, with comma",
+		Accepts:   []string{"p", "q"},
+	})
+	want := bindOutput{ID: 3, Limit: 2, Tags: []string{"x"}, UserAgent: "agent, with comma", Accepts: []string{"p", "q"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bound %+v, want %+v", got, want)
+	}
+}
+
+func TestBindParamsIgnoreBody(t *testing.T) {
+	type input struct {
+		Tenant *string  `header:"X-Tenant"`
+		Admin  *bool    `query:"admin"`
+		Roles  []string `cookie:"roles"`
+		Name   string   `json:"name"`
+	}
+	type output struct {
+		Tenant *string  `json:"tenant"`
+		Admin  *bool    `json:"admin"`
+		Roles  []string `json:"roles"`
+		Name   string   `json:"name"`
+	}
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodPost, "/accounts", func(c *gin.Context, in input) (output, error) {
+		return output(in), nil
+	})
+
+	// Parameters only come from their own source, never from the body.
+	recorder := serve(r.GinHandler, http.MethodPost, "/accounts", `{"Tenant": "evil", "Admin": true, "Roles": ["root"], "name": "n"}`, nil)
+	expectStatus(t, recorder, http.StatusOK)
+	var got output
+	decode(t, recorder, &got)
+	if !reflect.DeepEqual(got, output{Name: "n"}) {
+		t.Errorf("bound %+v, want the name only", got)
+	}
+
+	recorder = serve(r.GinHandler, http.MethodPost, "/accounts?admin=false", `{"Admin": true, "name": "n"}`, http.Header{"X-Tenant": {"acme"}})
+	expectStatus(t, recorder, http.StatusOK)
+	got = output{}
+	decode(t, recorder, &got)
+	if got.Tenant == nil || *got.Tenant != "acme" || got.Admin == nil || *got.Admin {
+		t.Errorf("bound %+v, want tenant acme and admin false", got)
+	}
+}
@@ -0,0 +1,238 @@
+package fastapi
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ETag sets a strong ETag, the hash of the response body, on the route's
+// successful responses and answers GET requests whose If-None-Match lists
+// it with 304 Not Modified.
+func ETag() RouteOption {
+	return func(rt *route) {
+		rt.etag = true
+	}
+}
+
+// CacheControl sets the Cache-Control header of the route's responses, e.g.
+// "public, max-age=60".
+func CacheControl(directives string) RouteOption {
+	return func(rt *route) {
+		rt.cacheControl = directives
+	}
+}
+
+// ServerCache keeps the route's 200 responses for ttl in the router's
+// response cache, serving GET requests with the same parameters and
+// principal without calling the handler. Responses must not depend on
+// anything else, such as the body or other dependencies.
+func ServerCache(ttl time.Duration) RouteOption {
+	return func(rt *route) {
+		rt.cacheTTL = ttl
+	}
+}
+
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// ResponseCache keeps the responses of routes with a ServerCache.
+type ResponseCache interface {
+	// Get returns the response at key, nil when there is none.
+	Get(ctx context.Context, key string) (*CachedResponse, error)
+	Set(ctx context.Context, key string, response CachedResponse, ttl time.Duration) error
+}
+
+// responseCaching holds the response cache of a router, shared by the router
+// and its groups.
+type responseCaching struct {
+	cache ResponseCache
+}
+
+// UseResponseCache keeps the responses of routes with a ServerCache in
+// cache, e.g. a RedisResponseCache shared by every instance of a service.
+// By default the last 1024 responses are kept in memory.
+func (r *Router) UseResponseCache(cache ResponseCache) {
+	r.caching.cache = cache
+}
+
+func (rt *route) cached() bool {
+	if bodyType := responseBodyType(rt.outputType); bodyType != nil && bodyType.Implements(rawBodyType) {
+		return false
+	}
+	return rt.etag || rt.cacheTTL > 0
+}
+
+// serveCached calls the handler and writes its response unless the response
+// cache has it, tagging it and answering conditional requests.
+func serveCached(c *gin.Context, rt *route, mediaType string, inputVal interface{}, invoke func() (interface{}, error)) error {
+	conditional := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+	cache := rt.group.caching.cache
+	var key string
+	if rt.cacheTTL > 0 && conditional {
+		key = cacheKey(c, rt, mediaType, reflect.ValueOf(inputVal).Elem())
+		cached, err := cache.Get(c.Request.Context(), key)
+		if err != nil {
+			log.Printf("fastapi: response cache of %s %s not read: %v", rt.method, rt.path, err)
+		}
+		if cached != nil {
+			header := c.Writer.Header()
+			for name, values := range cached.Header.Clone() {
+				header[name] = values
+			}
+			writeConditional(c, cached.Status, cached.Body)
+			return nil
+		}
+	}
+
+	before := c.Writer.Header().Clone()
+	output, err := invoke()
+	if err != nil {
+		return err
+	}
+	writer := &recordingWriter{ResponseWriter: c.Writer, hold: true, status: http.StatusOK}
+	c.Writer = writer
+	err = writeResponse(c, rt, mediaType, output)
+	c.Writer = writer.ResponseWriter
+	if err != nil {
+		return err
+	}
+
+	header := c.Writer.Header()
+	body := writer.body.Bytes()
+	if rt.etag && writer.status >= 200 && writer.status < 300 {
+		header.Set("ETag", `"`+hashHex(string(body))[:32]+`"`)
+	}
+	if key != "" && writer.status == http.StatusOK {
+		// Only the headers of the response are kept, not those of the
+		// request's rate limits, deprecation and the like.
+		set := make(http.Header)
+		for name, values := range header {
+			if strings.Join(values, ",") != strings.Join(before[name], ",") {
+				set[name] = values
+			}
+		}
+		err := cache.Set(context.WithoutCancel(c.Request.Context()), key, CachedResponse{Status: writer.status, Header: set, Body: body}, rt.cacheTTL)
+		if err != nil {
+			log.Printf("fastapi: response of %s %s not cached: %v", rt.method, rt.path, err)
+		}
+	}
+	writeConditional(c, writer.status, body)
+	return nil
+}
+
+// cacheKey identifies a cached response by the route, media type and
+// principal of a request and the values bound to its parameters.
+func cacheKey(c *gin.Context, rt *route, mediaType string, inputVal reflect.Value) string {
+	subject := ""
+	if principal := PrincipalFrom(c); principal != nil {
+		subject = principal.Scheme + ":" + principal.Subject
+	}
+	parts := []string{rt.method, rt.path, mediaType, subject}
+	for _, in := range parameterLocations {
+		eachTaggedField(inputVal, in, func(name string, field reflect.Value, _ reflect.StructField) error {
+			parts = append(parts, in, name, fmt.Sprintf("%q", formatParam(field)))
+			return nil
+		})
+	}
+	return hashHex(parts...)
+}
+
+// writeConditional writes a response, or 304 when it is that of a GET
+// request whose If-None-Match lists its ETag.
+func writeConditional(c *gin.Context, status int, body []byte) {
+	etag := c.Writer.Header().Get("ETag")
+	if etag != "" && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) && etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Length")
+		c.Writer.WriteHeader(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Writer.WriteHeader(status)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Write(body)
+}
+
+// etagMatches applies the weak comparison If-None-Match calls for.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryResponseCache keeps the most recently used responses in memory, for
+// a single instance of a service.
+type MemoryResponseCache struct {
+	mu       sync.Mutex
+	capacity int
+	// entries holds the *memoryCacheEntry of keys, most recently used first.
+	entries *list.List
+	keys    map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key      string
+	response CachedResponse
+	expires  time.Time
+}
+
+// NewMemoryResponseCache returns a cache of up to capacity responses.
+func NewMemoryResponseCache(capacity int) *MemoryResponseCache {
+	if capacity <= 0 {
+		panic("Response cache capacity must be positive")
+	}
+	return &MemoryResponseCache{capacity: capacity, entries: list.New(), keys: make(map[string]*list.Element)}
+}
+
+func (m *MemoryResponseCache) Get(_ context.Context, key string) (*CachedResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	element, present := m.keys[key]
+	if !present {
+		return nil, nil
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expires) {
+		m.entries.Remove(element)
+		delete(m.keys, key)
+		return nil, nil
+	}
+	m.entries.MoveToFront(element)
+	response := entry.response
+	return &response, nil
+}
+
+func (m *MemoryResponseCache) Set(_ context.Context, key string, response CachedResponse, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := &memoryCacheEntry{key: key, response: response, expires: time.Now().Add(ttl)}
+	if element, present := m.keys[key]; present {
+		element.Value = entry
+		m.entries.MoveToFront(element)
+		return nil
+	}
+	m.keys[key] = m.entries.PushFront(entry)
+	if m.entries.Len() > m.capacity {
+		oldest := m.entries.Back()
+		m.entries.Remove(oldest)
+		delete(m.keys, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
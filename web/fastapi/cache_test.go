@@ -0,0 +1,92 @@
+package fastapi_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"testing"
+	"time"
+	"web/fastapi"
+)
+
+type cachedInput struct {
+	// Neither field is encoded in JSON, both must key the cache.
+	ID    string `path:"id" json:"-"`
+	Color string `query:"color" default:"blue" json:"-"`
+}
+
+type cachedOutput struct {
+	ID      string `json:"id"`
+	Color   string `json:"color"`
+	Subject string `json:"subject"`
+	Call    int    `json:"call"`
+}
+
+func cacheRouter() *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Secure(fastapi.Require(fastapi.APIKeyAuth("key", "header", "X-API-Key", func(c *gin.Context, key string) (*fastapi.Principal, error) {
+		return &fastapi.Principal{Subject: key}, nil
+	})))
+	calls := 0
+	r.Handle(http.MethodGet, "/items/{id}", func(c *gin.Context, in cachedInput) (cachedOutput, error) {
+		calls++
+		return cachedOutput{ID: in.ID, Color: in.Color, Subject: fastapi.PrincipalFrom(c).Subject, Call: calls}, nil
+	}, fastapi.ServerCache(time.Minute), fastapi.ETag())
+	return r
+}
+
+func TestServerCache(t *testing.T) {
+	r := cacheRouter()
+	get := func(target, key string) cachedOutput {
+		t.Helper()
+		recorder := serve(r.GinHandler, http.MethodGet, target, "", http.Header{"X-Api-Key": {key}})
+		expectStatus(t, recorder, http.StatusOK)
+		var out cachedOutput
+		decode(t, recorder, &out)
+		return out
+	}
+
+	first := get("/items/1", "alice")
+	if again := get("/items/1", "alice"); again != first {
+		t.Errorf("repeated request served %+v, want the cached %+v", again, first)
+	}
+	tests := []struct {
+		name   string
+		target string
+		key    string
+		want   cachedOutput
+	}{
+		{"path parameter", "/items/2", "alice", cachedOutput{ID: "2", Color: "blue", Subject: "alice"}},
+		{"query parameter", "/items/1?color=red", "alice", cachedOutput{ID: "1", Color: "red", Subject: "alice"}},
+		{"principal", "/items/1", "bob", cachedOutput{ID: "1", Color: "blue", Subject: "bob"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := get(test.target, test.key)
+			if out.Call == first.Call {
+				t.Errorf("served the cached response of another request: %+v", out)
+			}
+			out.Call = 0
+			if out != test.want {
+				t.Errorf("served %+v, want %+v", out, test.want)
+			}
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	r := cacheRouter()
+	alice := http.Header{"X-Api-Key": {"alice"}}
+	recorder := serve(r.GinHandler, http.MethodGet, "/items/1", "", alice)
+	expectStatus(t, recorder, http.StatusOK)
+	etag := recorder.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	recorder = serve(r.GinHandler, http.MethodGet, "/items/1", "", http.Header{"X-Api-Key": {"alice"}, "If-None-Match": {etag}})
+	expectStatus(t, recorder, http.StatusNotModified)
+	if recorder.Body.Len() != 0 {
+		t.Errorf("304 with body %q", recorder.Body.String())
+	}
+	recorder = serve(r.GinHandler, http.MethodGet, "/items/1", "", http.Header{"X-Api-Key": {"alice"}, "If-None-Match": {`"other"`}})
+	expectStatus(t, recorder, http.StatusOK)
+}
@@ -0,0 +1,307 @@
+package fastapi
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the routes of a router over HTTP. It is the runtime of the
+// clients written by GenerateClient: inputs are encoded with the same tags
+// the router binds them from and errors are decoded into *Error.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+	retries    int
+	backoff    time.Duration
+}
+
+type ClientOption func(*Client)
+
+// NewClient returns a client of the router mounted at baseURL, e.g.
+// "http://users.internal/api". Idempotent requests failing on the network or
+// with 429, 502, 503 or 504 are retried twice by default.
+func NewClient(baseURL string, opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		header:     make(http.Header),
+		retries:    2,
+		backoff:    100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeader sends a header with every request, e.g. Authorization.
+func WithHeader(name, value string) ClientOption {
+	return func(c *Client) {
+		c.header.Add(name, value)
+	}
+}
+
+// WithRetries sets how many times failed requests are retried, waiting
+// backoff and then twice as long every time unless the server sends
+// Retry-After. Zero disables retries.
+func WithRetries(retries int, backoff time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// Do calls the route at path, a pattern such as "/users/{id}", with the
+// input in. The response body is decoded into out, which is nil for routes
+// without one and an *io.ReadCloser, left for the caller to close, for
+// streams.
+func (c *Client) Do(ctx context.Context, method, path string, in, out interface{}) error {
+	inputVal := reflect.ValueOf(in)
+	target, err := c.url(path, inputVal)
+	if err != nil {
+		return err
+	}
+	body, contentType, err := requestBody(method, inputVal)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		for name, values := range c.header {
+			req.Header[name] = append([]string{}, values...)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Accept", "application/json")
+		setRequestParams(req, inputVal)
+
+		resp, err := c.httpClient.Do(req)
+		wait, retry := c.shouldRetry(method, attempt, resp, err)
+		if !retry {
+			if err != nil {
+				return err
+			}
+			return decodeResponse(resp, out)
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// shouldRetry decides whether a request is worth another attempt and how
+// long to wait before it.
+func (c *Client) shouldRetry(method string, attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= c.retries || !isIdempotent(method) {
+		return 0, false
+	}
+	if err == nil {
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		default:
+			return 0, false
+		}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	wait := c.backoff << attempt
+	// Jitter keeps clients failing together from retrying together.
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1)), true
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPatch:
+		return false
+	}
+	return true
+}
+
+func (c *Client) url(path string, inputVal reflect.Value) (string, error) {
+	values := make(map[string]string)
+	eachTaggedField(inputVal, "path", func(name string, field reflect.Value, _ reflect.StructField) error {
+		if formatted := formatParam(field); len(formatted) > 0 {
+			values[name] = formatted[0]
+		}
+		return nil
+	})
+	segments := splitPath(path)
+	for i, segment := range segments {
+		if name, ok := paramName(segment); ok {
+			value, present := values[name]
+			if !present {
+				return "", fmt.Errorf("fastapi: missing path parameter %s", name)
+			}
+			segments[i] = url.PathEscape(value)
+		}
+	}
+
+	query := make(url.Values)
+	eachTaggedField(inputVal, "query", func(name string, field reflect.Value, _ reflect.StructField) error {
+		query[name] = append(query[name], formatParam(field)...)
+		return nil
+	})
+	target := c.baseURL + "/" + strings.Join(segments, "/")
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return target, nil
+}
+
+func setRequestParams(req *http.Request, inputVal reflect.Value) {
+	eachTaggedField(inputVal, "header", func(name string, field reflect.Value, _ reflect.StructField) error {
+		for _, value := range formatParam(field) {
+			req.Header.Add(name, value)
+		}
+		return nil
+	})
+	eachTaggedField(inputVal, "cookie", func(name string, field reflect.Value, _ reflect.StructField) error {
+		if formatted := formatParam(field); len(formatted) > 0 {
+			req.AddCookie(&http.Cookie{Name: name, Value: formatted[0]})
+		}
+		return nil
+	})
+}
+
+// requestBody encodes the form or JSON body fields of the input.
+func requestBody(method string, inputVal reflect.Value) ([]byte, string, error) {
+	if !hasRequestBody(method) {
+		return nil, "", nil
+	}
+	inputType := inputVal.Type()
+	if hasFormFields(inputType) {
+		if hasFileFields(inputType) {
+			return nil, "", fmt.Errorf("fastapi: clients cannot upload files")
+		}
+		form := make(url.Values)
+		eachTaggedField(inputVal, "form", func(name string, field reflect.Value, _ reflect.StructField) error {
+			form[name] = append(form[name], formatParam(field)...)
+			return nil
+		})
+		return []byte(form.Encode()), "application/x-www-form-urlencoded", nil
+	}
+	if !hasBodyFields(inputType) {
+		return nil, "", nil
+	}
+
+	data, err := json.Marshal(inputVal.Interface())
+	if err != nil || inputType.Kind() != reflect.Struct {
+		return data, "application/json", err
+	}
+	// Parameter fields are sent where the router binds them from, not in
+	// the body.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, "", err
+	}
+	body := make(map[string]json.RawMessage)
+	for _, field := range jsonFields(inputType) {
+		if value, present := fields[field.name]; present {
+			body[field.name] = value
+		}
+	}
+	data, err = json.Marshal(body)
+	return data, "application/json", err
+}
+
+// formatParam is the reverse of setFromStrings. Nil pointers have no value.
+func formatParam(field reflect.Value) []string {
+	if isListParam(field.Type()) {
+		var values []string
+		for i := 0; i < field.Len(); i++ {
+			values = append(values, formatParam(field.Index(i))...)
+		}
+		return values
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil
+		}
+		return formatParam(field.Elem())
+	}
+	if marshaler, ok := field.Interface().(encoding.TextMarshaler); ok {
+		text, _ := marshaler.MarshalText()
+		return []string{string(text)}
+	}
+	return []string{fmt.Sprint(field.Interface())}
+}
+
+func decodeResponse(resp *http.Response, out interface{}) error {
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return decodeProblem(resp)
+	}
+	if stream, ok := out.(*io.ReadCloser); ok {
+		*stream = resp.Body
+		return nil
+	}
+	defer resp.Body.Close()
+	if out == nil || !bodyAllowed(resp.StatusCode) {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	envelope := struct {
+		Response interface{} `json:"response"`
+	}{out}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("fastapi: decoding response: %w", err)
+	}
+	return nil
+}
+
+// decodeProblem turns an error response into an *Error, whose details are
+// []FieldError for validation failures.
+func decodeProblem(resp *http.Response) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var problem struct {
+		Problem
+		Details json.RawMessage `json:"details,omitempty"`
+	}
+	if json.Unmarshal(data, &problem) != nil || problem.Status == 0 {
+		return NewError(resp.StatusCode, "", strings.TrimSpace(string(data)))
+	}
+	decoded := NewError(problem.Status, problem.Code, problem.Detail)
+	if len(problem.Details) > 0 {
+		if problem.Code == "validation_failed" {
+			var fieldErrors []FieldError
+			json.Unmarshal(problem.Details, &fieldErrors)
+			decoded.Details = fieldErrors
+		} else {
+			json.Unmarshal(problem.Details, &decoded.Details)
+		}
+	}
+	return decoded
+}
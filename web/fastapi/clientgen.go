@@ -0,0 +1,242 @@
+package fastapi
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ClientConfig struct {
+	// Package is the name of the generated package, "client" by default.
+	Package string
+}
+
+// GenerateClient writes the source of a Go package calling the router's
+// routes: a Client type with one method per route taking a context and the
+// route's input and returning its output, built on the runtime in Client.
+// Input and output types must be importable, so not declared in package
+// main. Event stream, WebSocket and file upload routes are left out.
+//
+// It is meant to be run by go generate, from a small program building the
+// router:
+//
+//	//go:generate go run ./cmd/genclient
+//
+//	func main() {
+//		r := api.NewRouter()
+//		var src bytes.Buffer
+//		if err := r.GenerateClient(&src, fastapi.ClientConfig{}); err != nil {
+//			log.Fatal(err)
+//		}
+//		os.WriteFile("client/client.go", src.Bytes(), 0o644)
+//	}
+func (r *Router) GenerateClient(w io.Writer, config ClientConfig) error {
+	if config.Package == "" {
+		config.Package = "client"
+	}
+	gen := &clientGenerator{
+		imports: map[string]string{
+			"context":                          "context",
+			reflect.TypeOf(Client{}).PkgPath(): "fastapi",
+		},
+		taken: map[string]bool{"context": true, "fastapi": true, "io": true},
+	}
+
+	var methods strings.Builder
+	var skipped []string
+	methodNames := map[string]bool{"Do": true}
+	operations, _ := r.describe()
+	for _, operation := range operations {
+		rt := r.routesMap[operation.path][operation.method]
+		endpoint := operation.method + " " + operation.path
+		switch {
+		case rt.events != nil:
+			skipped = append(skipped, endpoint+" (server-sent events)")
+			continue
+		case rt.inbound != nil:
+			skipped = append(skipped, endpoint+" (WebSocket)")
+			continue
+		case hasFileFields(rt.inputType):
+			skipped = append(skipped, endpoint+" (file upload)")
+			continue
+		}
+		if err := gen.method(&methods, uniqueName(camelCase(operation.op.ID), methodNames), operation, rt); err != nil {
+			return fmt.Errorf("fastapi: generating client for %s: %w", endpoint, err)
+		}
+	}
+
+	var src strings.Builder
+	fmt.Fprintf(&src, "// Code generated by fastapi; DO NOT EDIT.\n\npackage %s\n\n", config.Package)
+	paths := make([]string, 0, len(gen.imports))
+	for path := range gen.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	src.WriteString("import (\n")
+	for _, path := range paths {
+		if name := gen.imports[path]; name != path[strings.LastIndex(path, "/")+1:] {
+			fmt.Fprintf(&src, "\t%s %s\n", name, strconv.Quote(path))
+		} else {
+			fmt.Fprintf(&src, "\t%s\n", strconv.Quote(path))
+		}
+	}
+	src.WriteString(")\n\n")
+	src.WriteString("// Client calls the API's routes.\ntype Client struct {\n\t*fastapi.Client\n}\n\n")
+	src.WriteString("// New returns a client of the API served at baseURL.\n")
+	src.WriteString("func New(baseURL string, opts ...fastapi.ClientOption) *Client {\n\treturn &Client{fastapi.NewClient(baseURL, opts...)}\n}\n")
+	src.WriteString(methods.String())
+	if len(skipped) > 0 {
+		src.WriteString("\n// Not generated:\n")
+		for _, endpoint := range skipped {
+			fmt.Fprintf(&src, "//   - %s\n", endpoint)
+		}
+	}
+
+	formatted, err := format.Source([]byte(src.String()))
+	if err != nil {
+		return fmt.Errorf("fastapi: formatting client: %w", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+type clientGenerator struct {
+	// import path -> name
+	imports map[string]string
+	taken   map[string]bool
+}
+
+func (g *clientGenerator) method(w *strings.Builder, name string, operation operationSpec, rt *route) error {
+	input, err := g.typeExpr(rt.inputType)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n// %s calls %s %s.\n", name, operation.method, operation.path)
+	if summary := operation.op.Summary; summary != "" {
+		fmt.Fprintf(w, "//\n// %s\n", strings.ReplaceAll(summary, "\n", "\n// "))
+	}
+	if operation.op.Deprecated {
+		w.WriteString("//\n// Deprecated: the route is deprecated.\n")
+	}
+	signature := fmt.Sprintf("func (c *Client) %s(ctx context.Context, in %s)", name, input)
+	call := fmt.Sprintf("c.Do(ctx, %q, %q, in", operation.method, operation.path)
+
+	bodyType := responseBodyType(rt.outputType)
+	switch {
+	case bodyType == nil:
+		fmt.Fprintf(w, "%s error {\n\treturn %s, nil)\n}\n", signature, call)
+		return nil
+	case bodyType == streamType:
+		g.imports["io"] = "io"
+		fmt.Fprintf(w, "%s (io.ReadCloser, error) {\n\tvar out io.ReadCloser\n\terr := %s, &out)\n\treturn out, err\n}\n", signature, call)
+		return nil
+	}
+	output, err := g.typeExpr(bodyType)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s (%s, error) {\n\tvar out %s\n\terr := %s, &out)\n\treturn out, err\n}\n", signature, output, output, call)
+	return nil
+}
+
+// qualifiedName matches the package qualified type names in the type
+// arguments of a generic type's name, e.g. "example.com/models.User".
+var qualifiedName = regexp.MustCompile(`([\w.\-~/]+)\.([A-Za-z_]\w*)`)
+
+// typeExpr returns the Go expression of a type in the generated package,
+// importing the packages it needs.
+func (g *clientGenerator) typeExpr(t reflect.Type) (string, error) {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name(), nil
+		}
+		if t.PkgPath() == "main" {
+			return "", fmt.Errorf("%s is declared in package main", t)
+		}
+		name := t.Name()
+		if !isExportedName(name) {
+			return "", fmt.Errorf("%s is not exported", t)
+		}
+		packageName, _, _ := strings.Cut(t.String(), ".")
+		prefix := g.importAs(t.PkgPath(), packageName) + "."
+		if base, args, generic := strings.Cut(name, "["); generic {
+			var err error
+			args = qualifiedName.ReplaceAllStringFunc(args, func(match string) string {
+				groups := qualifiedName.FindStringSubmatch(match)
+				if groups[1] == "main" {
+					err = fmt.Errorf("%s is declared in package main", match)
+				}
+				return g.importAs(groups[1], groups[1][strings.LastIndex(groups[1], "/")+1:]) + "." + groups[2]
+			})
+			return prefix + base + "[" + args, err
+		}
+		return prefix + name, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, err := g.typeExpr(t.Elem())
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := g.typeExpr(t.Elem())
+		return "[]" + elem, err
+	case reflect.Array:
+		elem, err := g.typeExpr(t.Elem())
+		return fmt.Sprintf("[%d]%s", t.Len(), elem), err
+	case reflect.Map:
+		key, err := g.typeExpr(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeExpr(t.Elem())
+		return "map[" + key + "]" + elem, err
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}", nil
+		}
+	case reflect.Struct:
+		fields := make([]string, 0, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fieldType, err := g.typeExpr(field.Type)
+			if err != nil {
+				return "", err
+			}
+			declaration := fieldType
+			if !field.Anonymous {
+				declaration = field.Name + " " + fieldType
+			}
+			if tag := string(field.Tag); strings.Contains(tag, "`") {
+				declaration += " " + strconv.Quote(tag)
+			} else if tag != "" {
+				declaration += " `" + tag + "`"
+			}
+			fields = append(fields, declaration)
+		}
+		return "struct{" + strings.Join(fields, "; ") + "}", nil
+	}
+	return "", fmt.Errorf("%s cannot be used by a client", t)
+}
+
+func (g *clientGenerator) importAs(path, name string) string {
+	if imported, present := g.imports[path]; present {
+		return imported
+	}
+	name = strings.NewReplacer("-", "", ".", "").Replace(name)
+	imported := name
+	for i := 2; g.taken[imported]; i++ {
+		imported = name + strconv.Itoa(i)
+	}
+	g.taken[imported] = true
+	g.imports[path] = imported
+	return imported
+}
+
+func isExportedName(name string) bool {
+	return name != "" && 'A' <= name[0] && name[0] <= 'Z'
+}
@@ -0,0 +1,223 @@
+package fastapi_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"web/fastapi"
+)
+
+type Widget struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Owner string   `json:"owner"`
+}
+
+type WidgetInput struct {
+	ID    string   `path:"id"`
+	Tags  []string `query:"tag"`
+	Owner string   `header:"X-Owner" default:""`
+}
+
+type NewWidget struct {
+	Owner string `header:"X-Owner" default:""`
+	Name  string `json:"name" validate:"required,minlen=2"`
+}
+
+var errWidgetNotFound = fastapi.NewError(http.StatusNotFound, "widget_not_found", "no such widget")
+
+// widgetsRouter fails its first flaky calls with 503.
+func widgetsRouter(flaky int32) *fastapi.Router {
+	var calls atomic.Int32
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodGet, "/widgets/{id}", func(c *gin.Context, in WidgetInput) (Widget, error) {
+		if calls.Add(1) <= flaky {
+			c.Header("Retry-After", "0")
+			return Widget{}, fastapi.NewError(http.StatusServiceUnavailable, "unavailable", "try again")
+		}
+		if in.ID == "missing" {
+			return Widget{}, errWidgetNotFound
+		}
+		return Widget{ID: in.ID, Tags: in.Tags, Owner: in.Owner}, nil
+	}, fastapi.Errors(errWidgetNotFound))
+	r.Handle(http.MethodPost, "/widgets", func(c *gin.Context, in NewWidget) (fastapi.Response[Widget], error) {
+		if calls.Add(1) <= flaky {
+			return fastapi.Response[Widget]{}, fastapi.NewError(http.StatusServiceUnavailable, "unavailable", "try again")
+		}
+		return fastapi.Response[Widget]{Status: http.StatusCreated, Body: Widget{ID: "w1", Name: in.Name, Owner: in.Owner}}, nil
+	}, fastapi.Summary("Create a widget"))
+	r.Handle(http.MethodDelete, "/widgets/{id}", func(c *gin.Context, in WidgetInput) (fastapi.NoContent, error) {
+		return fastapi.NoContent{}, nil
+	}, fastapi.Deprecated())
+	r.Handle(http.MethodGet, "/widgets/{id}/manual", func(c *gin.Context, in WidgetInput) (fastapi.Stream, error) {
+		return fastapi.Stream{Reader: strings.NewReader("manual of " + in.ID), ContentType: "text/plain"}, nil
+	})
+	fastapi.Events(r, "/widgets/events", func(c *gin.Context, in struct{}) (<-chan fastapi.Event[Widget], error) {
+		return nil, nil
+	})
+	r.Handle(http.MethodPost, "/uploads", func(c *gin.Context, in uploadInput) (upload, error) {
+		return upload{}, nil
+	})
+	return r
+}
+
+func TestGenerateClient(t *testing.T) {
+	var src bytes.Buffer
+	if err := widgetsRouter(0).GenerateClient(&src, fastapi.ClientConfig{Package: "widgets"}); err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "client.go", src.Bytes(), parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", src.String(), err)
+	}
+	if file.Name.Name != "widgets" {
+		t.Errorf("package %s, want widgets", file.Name.Name)
+	}
+
+	signatures := make(map[string]string)
+	docs := make(map[string]string)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil {
+			var signature bytes.Buffer
+			printer.Fprint(&signature, fset, fn.Type)
+			signatures[fn.Name.Name] = signature.String()
+			docs[fn.Name.Name] = fn.Doc.Text()
+		}
+	}
+	want := map[string]string{
+		"GetWidgetsById":       "func(ctx context.Context, in fastapi_test.WidgetInput) (fastapi_test.Widget, error)",
+		"PostWidgets":          "func(ctx context.Context, in fastapi_test.NewWidget) (fastapi_test.Widget, error)",
+		"DeleteWidgetsById":    "func(ctx context.Context, in fastapi_test.WidgetInput) error",
+		"GetWidgetsByIdManual": "func(ctx context.Context, in fastapi_test.WidgetInput) (io.ReadCloser, error)",
+	}
+	if !reflect.DeepEqual(signatures, want) {
+		t.Errorf("methods %v, want %v", signatures, want)
+	}
+	if !strings.Contains(docs["PostWidgets"], "Create a widget") {
+		t.Errorf("PostWidgets documented %q, want its summary", docs["PostWidgets"])
+	}
+	if !strings.Contains(docs["DeleteWidgetsById"], "Deprecated: ") {
+		t.Errorf("DeleteWidgetsById documented %q, want a deprecation notice", docs["DeleteWidgetsById"])
+	}
+	for _, skipped := range []string{"GET /widgets/events (server-sent events)", "POST /uploads (file upload)"} {
+		if !strings.Contains(src.String(), skipped) {
+			t.Errorf("the client does not list %s as not generated", skipped)
+		}
+	}
+}
+
+func TestGenerateClientErrors(t *testing.T) {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodGet, "/items", func(c *gin.Context, in struct{}) (item, error) {
+		return item{}, nil
+	})
+	err := r.GenerateClient(io.Discard, fastapi.ClientConfig{})
+	if err == nil || !strings.Contains(err.Error(), "GET /items") || !strings.Contains(err.Error(), "not exported") {
+		t.Errorf("generated %v, want an error naming the route and the unexported type", err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	server := listen(t, widgetsRouter(0))
+	client := fastapi.NewClient(server.URL+"/", fastapi.WithHeader("X-Owner", "alice"))
+	ctx := context.Background()
+
+	var widget Widget
+	if err := client.Do(ctx, http.MethodGet, "/widgets/{id}", WidgetInput{ID: "a b", Tags: []string{"x", "y"}}, &widget); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Widget{ID: "a b", Tags: []string{"x", "y"}, Owner: "alice"}); !reflect.DeepEqual(widget, want) {
+		t.Errorf("got %+v, want %+v", widget, want)
+	}
+
+	widget = Widget{}
+	if err := client.Do(ctx, http.MethodPost, "/widgets", NewWidget{Name: "gear"}, &widget); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Widget{ID: "w1", Name: "gear", Owner: "alice"}); !reflect.DeepEqual(widget, want) {
+		t.Errorf("created %+v", widget)
+	}
+
+	if err := client.Do(ctx, http.MethodDelete, "/widgets/{id}", WidgetInput{ID: "w1"}, nil); err != nil {
+		t.Error(err)
+	}
+
+	var manual io.ReadCloser
+	if err := client.Do(ctx, http.MethodGet, "/widgets/{id}/manual", WidgetInput{ID: "w1"}, &manual); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(manual)
+	manual.Close()
+	if string(content) != "manual of w1" {
+		t.Errorf("streamed %q", content)
+	}
+
+	if err := client.Do(ctx, http.MethodGet, "/widgets/{id}", WidgetInput{}, nil); err == nil {
+		t.Error("called a route without its path parameter")
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	client := fastapi.NewClient(listen(t, widgetsRouter(0)).URL)
+	ctx := context.Background()
+
+	err := client.Do(ctx, http.MethodGet, "/widgets/{id}", WidgetInput{ID: "missing"}, &Widget{})
+	if !errors.Is(err, errWidgetNotFound) {
+		t.Errorf("got %v, want %v", err, errWidgetNotFound)
+	}
+
+	err = client.Do(ctx, http.MethodPost, "/widgets", NewWidget{Name: "x"}, &Widget{})
+	var apiErr *fastapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnprocessableEntity {
+		t.Fatalf("got %v, want a validation error", err)
+	}
+	details, _ := apiErr.Details.([]fastapi.FieldError)
+	if len(details) != 1 || details[0].In != "body" || details[0].Path != "name" {
+		t.Errorf("details %#v, want the name field", apiErr.Details)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		flaky   int32
+		method  string
+		path    string
+		in      interface{}
+		retries int
+		ok      bool
+	}{
+		{"retried", 2, http.MethodGet, "/widgets/{id}", WidgetInput{ID: "a"}, 2, true},
+		{"out of retries", 3, http.MethodGet, "/widgets/{id}", WidgetInput{ID: "a"}, 2, false},
+		{"disabled", 1, http.MethodGet, "/widgets/{id}", WidgetInput{ID: "a"}, 0, false},
+		{"unsafe methods are not retried", 1, http.MethodPost, "/widgets", NewWidget{Name: "gear"}, 2, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := listen(t, widgetsRouter(test.flaky))
+			client := fastapi.NewClient(server.URL, fastapi.WithRetries(test.retries, time.Millisecond))
+			err := client.Do(ctx, test.method, test.path, test.in, &Widget{})
+			if (err == nil) != test.ok {
+				t.Errorf("got %v, want success %v", err, test.ok)
+			}
+			if !test.ok && !errors.Is(err, fastapi.NewError(http.StatusServiceUnavailable, "unavailable", "")) {
+				t.Errorf("got %v, want the 503", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,528 @@
+package fastapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	openapi "github.com/go-openapi/spec"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// ContractError lists how a request or response departs from the router's
+// Swagger 2.0 document.
+type ContractError struct {
+	Method string
+	// Path is the route's pattern.
+	Path string
+	// Status is the status of the response, 0 for requests.
+	Status     int
+	Violations []string
+}
+
+func (e *ContractError) Error() string {
+	subject := "request"
+	if e.Status != 0 {
+		subject = strconv.Itoa(e.Status) + " response"
+	}
+	return fmt.Sprintf("%s %s: %s does not match the spec: %s", e.Method, e.Path, subject, strings.Join(e.Violations, "; "))
+}
+
+// ContractMode says what happens to requests and responses departing from
+// the router's spec.
+type ContractMode int32
+
+const (
+	// ContractOff skips the checks, the default.
+	ContractOff ContractMode = iota
+	// ContractLog logs violations.
+	ContractLog
+	// ContractEnforce logs violations, rejects requests violating the spec
+	// with 400 and replaces responses violating it with 500.
+	ContractEnforce
+)
+
+// contractCache holds the document requests and responses are checked
+// against until the router's routes change.
+type contractCache struct {
+	mu         sync.Mutex
+	generation uint64
+	spec       *openapi.Swagger
+	mode       atomic.Int32
+}
+
+// CheckContract checks every request and response GinHandler serves, on the
+// router and all its groups, against the document produced by the root
+// router's EmitOpenAPIDefinition, which describes every route, to catch
+// drift between the spec and the code in staging. Responses are checked as
+// with CheckResponse; those of streams, event streams and WebSockets are
+// not.
+func (r *Router) CheckContract(mode ContractMode) {
+	r.contract.mode.Store(int32(mode))
+}
+
+func (r *Router) contractSpec() *openapi.Swagger {
+	r.contract.mu.Lock()
+	defer r.contract.mu.Unlock()
+	if generation := r.generation.Load(); r.contract.spec == nil || r.contract.generation != generation {
+		spec := r.root().EmitOpenAPIDefinition()
+		r.contract.spec = &spec
+		r.contract.generation = generation
+	}
+	return r.contract.spec
+}
+
+// CheckResponse checks a response of the route serving method and path, a
+// path as passed to GinHandler, against the document produced by the root
+// router's EmitOpenAPIDefinition: the status must be documented, the body
+// must be of a documented media type and JSON bodies must match their
+// schema. It returns a *ContractError listing every violation, and nil for
+// requests no route serves. Null is accepted for arrays and maps, which
+// encoding/json writes for nil slices and maps.
+func (r *Router) CheckResponse(method, path string, status int, header http.Header, body []byte) error {
+	found, _ := r.tree.lookup(path)
+	if found == nil || found.methods[method] == nil {
+		return nil
+	}
+	spec := r.contractSpec()
+	op := operationFor(spec.Paths.Paths[found.pattern], method)
+	if op == nil {
+		return nil
+	}
+	violations := checkResponse(op, spec.Definitions, status, header, body)
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ContractError{Method: method, Path: found.pattern, Status: status, Violations: violations}
+}
+
+func operationFor(pi openapi.PathItem, method string) *openapi.Operation {
+	switch method {
+	case http.MethodGet:
+		return pi.Get
+	case http.MethodPost:
+		return pi.Post
+	case http.MethodPut:
+		return pi.Put
+	case http.MethodPatch:
+		return pi.Patch
+	case http.MethodDelete:
+		return pi.Delete
+	case http.MethodHead:
+		return pi.Head
+	case http.MethodOptions:
+		return pi.Options
+	}
+	return nil
+}
+
+func checkResponse(op *openapi.Operation, definitions openapi.Definitions, status int, header http.Header, body []byte) []string {
+	resp, documented := op.Responses.StatusCodeResponses[status]
+	if !documented {
+		return []string{"status " + strconv.Itoa(status) + " is not documented"}
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	if resp.Schema == nil {
+		return []string{"body is not documented"}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	canonical, _ := canonicalMediaType(mediaType)
+	offered := false
+	for _, produced := range op.Produces {
+		offered = offered || produced == mediaType || produced == canonical
+	}
+	if !offered {
+		return []string{"media type " + strconv.Quote(mediaType) + " is not documented"}
+	}
+	if mediaType != "application/json" && mediaType != problemContentType || resp.Schema.Type.Contains("file") {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []string{"body is not valid JSON: " + err.Error()}
+	}
+	checker := &schemaChecker{definitions: definitions}
+	checker.check("body", value, *resp.Schema)
+	return checker.violations
+}
+
+// checkRequest checks the parameters and the body of a request. Cookies
+// and multipart forms, which Swagger 2.0 cannot fully describe, are left to
+// the router's binding.
+func checkRequest(op *openapi.Operation, definitions openapi.Definitions, req *http.Request, pathParams map[string]string, body []byte) []string {
+	checker := &schemaChecker{definitions: definitions}
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if len(body) > 0 {
+		consumes := op.Consumes
+		if len(consumes) == 0 {
+			consumes = []string{binding.MIMEJSON}
+		}
+		documented := false
+		for _, consumed := range consumes {
+			documented = documented || consumed == mediaType
+		}
+		if !documented {
+			checker.fail("body", "media type %q is not documented", mediaType)
+			return checker.violations
+		}
+	}
+	var form url.Values
+	if mediaType == binding.MIMEPOSTForm {
+		form, _ = url.ParseQuery(string(body))
+	}
+
+	for _, param := range op.Parameters {
+		var values []string
+		switch param.In {
+		case "path":
+			if value, present := pathParams[param.Name]; present {
+				values = []string{value}
+			}
+		case "query":
+			values = req.URL.Query()[param.Name]
+		case "header":
+			values = req.Header.Values(param.Name)
+		case "formData":
+			if form == nil || param.Type == "file" {
+				continue
+			}
+			values = form[param.Name]
+		case "body":
+			if len(body) == 0 {
+				if param.Required {
+					checker.fail("body", "is required")
+				}
+				continue
+			}
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			var value interface{}
+			if err := decoder.Decode(&value); err != nil {
+				checker.fail("body", "is not valid JSON: %v", err)
+				continue
+			}
+			checker.check("body", value, *param.Schema)
+			continue
+		}
+
+		at := param.In + " " + param.Name
+		if len(values) == 0 {
+			if param.Required {
+				checker.fail(at, "is required")
+			}
+			continue
+		}
+		schema := simpleSchema(param.SimpleSchema, param.CommonValidations)
+		if param.Type != "array" {
+			checker.check(at, parameterValue(values[0], param.Type), schema)
+			continue
+		}
+		if param.CollectionFormat != "multi" {
+			values = splitList(strings.Join(values, ","))
+		}
+		items := make([]interface{}, len(values))
+		for i, value := range values {
+			items[i] = parameterValue(value, param.Items.Type)
+		}
+		checker.check(at, items, schema)
+	}
+	return checker.violations
+}
+
+// simpleSchema turns the description of a parameter into a schema.
+func simpleSchema(simple openapi.SimpleSchema, validations openapi.CommonValidations) openapi.Schema {
+	schema := new(openapi.Schema).Typed(simple.Type, simple.Format)
+	schema.WithValidations(openapi.SchemaValidations{CommonValidations: validations})
+	if simple.Items != nil {
+		items := simpleSchema(simple.Items.SimpleSchema, simple.Items.CommonValidations)
+		schema.Items = &openapi.SchemaOrArray{Schema: &items}
+	}
+	return *schema
+}
+
+// parameterValue converts a raw parameter to what its JSON counterpart
+// decodes to, leaving malformed values as strings for the checker to
+// reject.
+func parameterValue(raw, paramType string) interface{} {
+	switch paramType {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case "boolean":
+		if value, err := strconv.ParseBool(raw); err == nil {
+			return value
+		}
+	}
+	return raw
+}
+
+// schemaChecker validates decoded JSON against the subset of JSON Schema
+// the router emits.
+type schemaChecker struct {
+	definitions openapi.Definitions
+	violations  []string
+	patterns    map[string]*regexp.Regexp
+}
+
+func (c *schemaChecker) fail(at, format string, args ...interface{}) {
+	c.violations = append(c.violations, at+": "+fmt.Sprintf(format, args...))
+}
+
+func (c *schemaChecker) check(at string, value interface{}, schema openapi.Schema) {
+	nullable, _ := schema.Extensions.GetBool("x-nullable")
+	if ref := schema.Ref.String(); ref != "" {
+		definition, present := c.definitions[strings.TrimPrefix(ref, "#/definitions/")]
+		if !present {
+			c.fail(at, "unknown definition %s", ref)
+			return
+		}
+		schema = definition
+	}
+	if len(schema.Type) == 0 {
+		return
+	}
+	schemaType := schema.Type[0]
+
+	if value == nil {
+		if !nullable && schemaType != "array" && !(schemaType == "object" && schema.AdditionalProperties != nil) {
+			c.fail(at, "must not be null")
+		}
+		return
+	}
+
+	switch schemaType {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			c.fail(at, "expected an object")
+			return
+		}
+		for _, name := range schema.Required {
+			if _, present := object[name]; !present {
+				c.fail(at, "missing required property %s", name)
+			}
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, present := schema.Properties[name]; present {
+				c.check(at+"."+name, object[name], property)
+			} else if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+				c.check(at+"."+name, object[name], *schema.AdditionalProperties.Schema)
+			} else if schema.AdditionalProperties == nil {
+				c.fail(at, "unexpected property %s", name)
+			}
+		}
+		c.checkLength(at, len(object), "properties", schema.MinItems, schema.MaxItems)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			c.fail(at, "expected an array")
+			return
+		}
+		if schema.Items != nil && schema.Items.Schema != nil {
+			for i, item := range items {
+				c.check(at+"["+strconv.Itoa(i)+"]", item, *schema.Items.Schema)
+			}
+		}
+		c.checkLength(at, len(items), "items", schema.MinItems, schema.MaxItems)
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			c.fail(at, "expected a string")
+			return
+		}
+		c.checkLength(at, utf8.RuneCountInString(text), "characters", schema.MinLength, schema.MaxLength)
+		if schema.Pattern != "" && !c.pattern(schema.Pattern).MatchString(text) {
+			c.fail(at, "does not match %s", schema.Pattern)
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			c.fail(at, "expected a number")
+			return
+		}
+		if _, err := number.Int64(); err != nil && schemaType == "integer" {
+			c.fail(at, "expected an integer")
+			return
+		}
+		float, _ := number.Float64()
+		if schema.Minimum != nil && float < *schema.Minimum {
+			c.fail(at, "must be at least %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && float > *schema.Maximum {
+			c.fail(at, "must be at most %v", *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			c.fail(at, "expected a boolean")
+			return
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		for _, allowed := range schema.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				return
+			}
+		}
+		c.fail(at, "must be one of %v", schema.Enum)
+	}
+}
+
+func (c *schemaChecker) checkLength(at string, length int, unit string, min, max *int64) {
+	if min != nil && int64(length) < *min {
+		c.fail(at, "must have at least %d %s", *min, unit)
+	}
+	if max != nil && int64(length) > *max {
+		c.fail(at, "must have at most %d %s", *max, unit)
+	}
+}
+
+func (c *schemaChecker) pattern(expr string) *regexp.Regexp {
+	if c.patterns == nil {
+		c.patterns = make(map[string]*regexp.Regexp)
+	}
+	if compiled, present := c.patterns[expr]; present {
+		return compiled
+	}
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		compiled = regexp.MustCompile("")
+	}
+	c.patterns[expr] = compiled
+	return compiled
+}
+
+// serveChecked serves a request with serve, checking the request before and
+// the response after.
+func (r *Router) serveChecked(c *gin.Context, mode ContractMode, pattern string, rt *route, params map[string]string, serve func()) {
+	spec := r.contractSpec()
+	op := operationFor(spec.Paths.Paths[pattern], rt.method)
+	if op == nil {
+		serve()
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if tooLarge := bodyTooLarge(err); tooLarge != nil {
+		writeError(c, tooLarge)
+		return
+	}
+	if err != nil {
+		writeError(c, NewError(http.StatusBadRequest, "invalid_request", "unreadable body"))
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if violations := checkRequest(op, spec.Definitions, c.Request, params, body); len(violations) > 0 {
+		log.Printf("fastapi: %v", &ContractError{Method: rt.method, Path: pattern, Violations: violations})
+		if mode == ContractEnforce {
+			writeError(c, NewError(http.StatusBadRequest, "contract_violation", "request does not match the spec").WithDetails(violations))
+			return
+		}
+	}
+
+	if bodyType := responseBodyType(rt.outputType); bodyType != nil && bodyType.Implements(rawBodyType) {
+		serve()
+		return
+	}
+	writer := &recordingWriter{ResponseWriter: c.Writer, hold: mode == ContractEnforce, status: http.StatusOK}
+	c.Writer = writer
+	serve()
+	c.Writer = writer.ResponseWriter
+
+	if violations := checkResponse(op, spec.Definitions, writer.status, writer.Header(), writer.body.Bytes()); len(violations) > 0 {
+		log.Printf("fastapi: %v", &ContractError{Method: rt.method, Path: pattern, Status: writer.status, Violations: violations})
+		if mode == ContractEnforce {
+			writeError(c, NewError(http.StatusInternalServerError, "contract_violation", "response does not match the spec").WithDetails(violations))
+			return
+		}
+	}
+	if writer.hold {
+		c.Writer.WriteHeader(writer.status)
+		c.Writer.WriteHeaderNow()
+		c.Writer.Write(writer.body.Bytes())
+	}
+}
+
+// recordingWriter records a response for contract checks and idempotent
+// replays, holding it back when it may be replaced.
+type recordingWriter struct {
+	gin.ResponseWriter
+	hold    bool
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if status > 0 {
+		w.status = status
+	}
+	if !w.hold {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *recordingWriter) WriteHeaderNow() {
+	w.written = true
+	if !w.hold {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	w.body.Write(data)
+	if w.hold {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *recordingWriter) Status() int {
+	return w.status
+}
+
+func (w *recordingWriter) Written() bool {
+	return w.written
+}
+
+func (w *recordingWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *recordingWriter) Flush() {
+	if !w.hold {
+		w.ResponseWriter.Flush()
+	}
+}
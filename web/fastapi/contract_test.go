@@ -0,0 +1,121 @@
+package fastapi_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"testing"
+	"web/fastapi"
+)
+
+type drift struct {
+	Name string `json:"name"`
+}
+
+type driftQuery struct {
+	Limit int `query:"limit" default:"10"`
+}
+
+func contractRouter(mode fastapi.ContractMode) *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodGet, "/ok", func(c *gin.Context, in driftQuery) (drift, error) {
+		return drift{Name: "ok"}, nil
+	})
+	r.Handle(http.MethodGet, "/drifting", func(c *gin.Context, in struct{}) (fastapi.Response[drift], error) {
+		// 418 is not documented.
+		return fastapi.Response[drift]{Status: http.StatusTeapot}, nil
+	})
+	r.Handle(http.MethodPost, "/echo", func(c *gin.Context, in drift) (drift, error) {
+		return in, nil
+	}, fastapi.MaxBodySize(64))
+	r.Handle(http.MethodGet, "/panics", func(c *gin.Context, in struct{}) (drift, error) {
+		panic("boom")
+	})
+	r.CheckContract(mode)
+	return r
+}
+
+func TestContractEnforce(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		header http.Header
+		status int
+		code   string
+	}{
+		{"valid", http.MethodGet, "/ok?limit=5", "", nil, http.StatusOK, ""},
+		{"invalid request", http.MethodGet, "/ok?limit=many", "", nil, http.StatusBadRequest, "contract_violation"},
+		{"undocumented response", http.MethodGet, "/drifting", "", nil, http.StatusInternalServerError, "contract_violation"},
+		{"not acceptable", http.MethodGet, "/ok", "", http.Header{"Accept": {"text/html"}}, http.StatusNotAcceptable, "not_acceptable"},
+		{"malformed body", http.MethodPost, "/echo", "{", nil, http.StatusBadRequest, "contract_violation"},
+		{"body too large", http.MethodPost, "/echo", `{"name": "` + string(make([]byte, 100)) + `"}`, nil, http.StatusRequestEntityTooLarge, "body_too_large"},
+		{"panic", http.MethodGet, "/panics", "", nil, http.StatusInternalServerError, ""},
+	}
+	r := contractRouter(fastapi.ContractEnforce)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(r.GinHandler, test.method, test.target, test.body, test.header)
+			expectStatus(t, recorder, test.status)
+			if test.code != "" {
+				if code := problem(t, recorder).Code; code != test.code {
+					t.Errorf("code %q, want %q", code, test.code)
+				}
+			}
+		})
+	}
+}
+
+func TestContractLog(t *testing.T) {
+	r := contractRouter(fastapi.ContractLog)
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/ok?limit=many", "", nil), http.StatusUnprocessableEntity)
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/drifting", "", nil), http.StatusTeapot)
+}
+
+func TestCheckResponse(t *testing.T) {
+	r := contractRouter(fastapi.ContractOff)
+	json := http.Header{"Content-Type": {"application/json"}}
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		body   string
+		valid  bool
+	}{
+		{"valid", "/ok", http.StatusOK, `{"response": {"name": "x"}}`, true},
+		{"unrouted", "/nowhere", http.StatusOK, `{}`, true},
+		{"undocumented status", "/ok", http.StatusTeapot, `{}`, false},
+		{"wrong type", "/ok", http.StatusOK, `{"response": {"name": 1}}`, false},
+		{"unexpected property", "/ok", http.StatusOK, `{"response": {"name": "x", "extra": 1}}`, false},
+		{"missing envelope", "/ok", http.StatusOK, `{"name": "x"}`, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := r.CheckResponse(http.MethodGet, test.path, test.status, json, []byte(test.body))
+			if (err == nil) != test.valid {
+				t.Errorf("CheckResponse returned %v, want valid %v", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestContractAcrossVersions(t *testing.T) {
+	r := fastapi.NewRouter()
+	v1 := r.Version("v1")
+	v2 := r.Version("v2")
+	v1.Handle(http.MethodGet, "/a", func(c *gin.Context, in struct{}) (drift, error) {
+		return drift{Name: "v1"}, nil
+	})
+	v2.Handle(http.MethodGet, "/a", func(c *gin.Context, in struct{}) (drift, error) {
+		return drift{Name: "v2"}, nil
+	})
+	r.CheckContract(fastapi.ContractEnforce)
+
+	// The spec of v1 does not describe /v2/a, the one of the root does.
+	expectStatus(t, serve(v1.GinHandler, http.MethodGet, "/v1/a", "", nil), http.StatusOK)
+	expectStatus(t, serve(v1.GinHandler, http.MethodGet, "/v2/a", "", nil), http.StatusNotFound)
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/v2/a", "", nil), http.StatusOK)
+	if err := v2.CheckResponse(http.MethodGet, "/v2/a", http.StatusOK, http.Header{"Content-Type": {"application/json"}}, []byte(`{"response": {"name": "v2"}}`)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,127 @@
+package fastapi
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"reflect"
+)
+
+// dependsTag marks input fields filled by a provider instead of the request:
+//
+//	type UpdateInput struct {
+//		User CurrentUser `depends:""`
+//		Name string      `json:"name"`
+//	}
+const dependsTag = "depends"
+
+const (
+	routerContextKey       = "fastapi.router"
+	dependenciesContextKey = "fastapi.dependencies"
+)
+
+type provider func(c *gin.Context) (interface{}, error)
+
+// Provide registers how to resolve values of type T for the router. A
+// provider runs at most once per request, its error short-circuits the
+// request like a handler error. Providers are registered before the routes
+// depending on them, which panic otherwise.
+func Provide[T any](r *Router, resolve func(*gin.Context) (T, error)) {
+	r.providers[reflect.TypeOf((*T)(nil)).Elem()] = func(c *gin.Context) (interface{}, error) {
+		return resolve(c)
+	}
+}
+
+// Resolve returns the value of type T for the current request, so that
+// providers, middleware and handlers can build on other dependencies.
+func Resolve[T any](c *gin.Context) (T, error) {
+	var zero T
+	value, err := resolveType(c, reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return zero, err
+	}
+	typed, _ := value.(T)
+	return typed, nil
+}
+
+func resolveType(c *gin.Context, goType reflect.Type) (interface{}, error) {
+	cached, _ := c.Get(dependenciesContextKey)
+	resolved, _ := cached.(map[reflect.Type]interface{})
+	if resolved == nil {
+		resolved = make(map[reflect.Type]interface{})
+		c.Set(dependenciesContextKey, resolved)
+	}
+	if value, present := resolved[goType]; present {
+		return value, nil
+	}
+
+	handling, _ := c.Get(routerContextKey)
+	r, _ := handling.(*Router)
+	if r == nil {
+		return nil, fmt.Errorf("fastapi: no router handling this request")
+	}
+	resolve, present := r.providers[goType]
+	if !present {
+		return nil, fmt.Errorf("fastapi: no provider for %s", goType)
+	}
+	value, err := resolve(c)
+	if err != nil {
+		return nil, err
+	}
+	resolved[goType] = value
+	return value, nil
+}
+
+// Depends adds dependencies run for their side effects before the route's
+// input is bound, e.g. checking a permission.
+func Depends(dependencies ...func(*gin.Context) error) RouteOption {
+	return func(rt *route) {
+		rt.dependencies = append(rt.dependencies, dependencies...)
+	}
+}
+
+// checkDependencies panics unless every depends field of the input type has
+// a provider. Those resolved with Resolve are only known once called.
+func (r *Router) checkDependencies(inputType reflect.Type) {
+	if inputType.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < inputType.NumField(); i++ {
+		field := inputType.Field(i)
+		if _, present := field.Tag.Lookup(dependsTag); !present || !field.IsExported() {
+			continue
+		}
+		if _, present := r.providers[field.Type]; !present {
+			panic("No provider for " + field.Type.String() + " of " + field.Name + ", register it with Provide first")
+		}
+	}
+}
+
+// resolveDependencies resolves every depends field of the input type and
+// returns a function assigning them to a bound input value.
+func resolveDependencies(c *gin.Context, inputType reflect.Type) (func(reflect.Value), error) {
+	var fields []int
+	var values []reflect.Value
+	if inputType.Kind() == reflect.Struct {
+		for i := 0; i < inputType.NumField(); i++ {
+			field := inputType.Field(i)
+			if _, present := field.Tag.Lookup(dependsTag); !present || !field.IsExported() {
+				continue
+			}
+			value, err := resolveType(c, field.Type)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, i)
+			if value == nil {
+				values = append(values, reflect.Zero(field.Type))
+			} else {
+				values = append(values, reflect.ValueOf(value))
+			}
+		}
+	}
+	return func(inputVal reflect.Value) {
+		for i, field := range fields {
+			inputVal.Field(field).Set(values[i])
+		}
+	}, nil
+}
@@ -0,0 +1,86 @@
+package fastapi_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"testing"
+	"web/fastapi"
+)
+
+type tenant struct {
+	Name string
+}
+
+type tenantInput struct {
+	Tenant tenant `depends:""`
+}
+
+type tenantOutput struct {
+	Tenant string `json:"tenant"`
+}
+
+func TestDependencies(t *testing.T) {
+	r := fastapi.NewRouter()
+	calls := 0
+	fastapi.Provide(r, func(c *gin.Context) (tenant, error) {
+		calls++
+		return tenant{Name: c.GetHeader("X-Tenant")}, nil
+	})
+	r.Use(func(c *gin.Context, next func() error) error {
+		// Resolved once per request, shared with the handler.
+		if _, err := fastapi.Resolve[tenant](c); err != nil {
+			return err
+		}
+		return next()
+	})
+	r.Handle(http.MethodGet, "/tenant", func(c *gin.Context, in tenantInput) (tenantOutput, error) {
+		return tenantOutput{Tenant: in.Tenant.Name}, nil
+	})
+
+	recorder := serve(r.GinHandler, http.MethodGet, "/tenant", "", http.Header{"X-Tenant": {"acme"}})
+	expectStatus(t, recorder, http.StatusOK)
+	var out tenantOutput
+	decode(t, recorder, &out)
+	if out.Tenant != "acme" || calls != 1 {
+		t.Errorf("tenant %q resolved %d times, want acme once", out.Tenant, calls)
+	}
+}
+
+func TestMissingProviderPanicsAtRegistration(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registered without a provider")
+		}
+	}()
+	fastapi.NewRouter().Handle(http.MethodGet, "/tenant", func(c *gin.Context, in tenantInput) (tenantOutput, error) {
+		return tenantOutput{}, nil
+	})
+}
+
+func TestMiddlewareRunsAfterSecurity(t *testing.T) {
+	r := fastapi.NewRouter()
+	r.Secure(fastapi.Require(fastapi.APIKeyAuth("key", "header", "X-API-Key", func(c *gin.Context, key string) (*fastapi.Principal, error) {
+		if key != "secret" {
+			return nil, fastapi.ErrUnauthorized
+		}
+		return &fastapi.Principal{Subject: "alice"}, nil
+	})))
+	var seen []string
+	r.Use(func(c *gin.Context, next func() error) error {
+		subject := ""
+		if principal := fastapi.PrincipalFrom(c); principal != nil {
+			subject = principal.Subject
+		}
+		seen = append(seen, subject)
+		return next()
+	})
+	r.Handle(http.MethodGet, "/me", func(c *gin.Context, in struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	})
+
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/me", "", nil), http.StatusUnauthorized)
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/me", "", http.Header{"X-Api-Key": {"secret"}}), http.StatusOK)
+	if len(seen) != 1 || seen[0] != "alice" {
+		t.Errorf("middleware saw %q, want only alice", seen)
+	}
+}
@@ -0,0 +1,143 @@
+package fastapi
+
+import (
+	"embed"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	openapi "github.com/go-openapi/spec"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+//go:embed docs
+var docsAssets embed.FS
+
+type DocsConfig struct {
+	Title       string
+	Version     string
+	Description string
+	// Servers are the base URLs GinHandler is mounted under, e.g. "/api".
+	Servers []OpenAPIServer
+}
+
+// specCache holds the rendered documents until the router's routes change.
+type specCache struct {
+	mu         sync.Mutex
+	generation uint64
+	swagger    []byte
+	openapi    []byte
+	yaml       []byte
+}
+
+// MountDocs serves the specification and the docs page on g:
+//
+//	GET /openapi.json  OpenAPI 3.1
+//	GET /openapi.yaml  OpenAPI 3.1
+//	GET /swagger.json  Swagger 2.0
+//	GET /docs          interactive docs page, fully offline
+func (r *Router) MountDocs(g gin.IRouter, config DocsConfig) {
+	assets, err := fs.Sub(docsAssets, "docs")
+	if err != nil {
+		panic(err)
+	}
+	index, err := fs.ReadFile(assets, "index.html")
+	if err != nil {
+		panic(err)
+	}
+	cache := &specCache{}
+
+	serve := func(contentType string, pick func(*specCache) []byte) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			cache.mu.Lock()
+			err := r.refreshSpecs(cache, config)
+			data := pick(cache)
+			cache.mu.Unlock()
+			if err != nil {
+				writeError(c, err)
+				return
+			}
+			c.Data(http.StatusOK, contentType, data)
+		}
+	}
+	g.GET("/openapi.json", serve("application/json", func(cache *specCache) []byte { return cache.openapi }))
+	g.GET("/openapi.yaml", serve("application/yaml", func(cache *specCache) []byte { return cache.yaml }))
+	g.GET("/swagger.json", serve("application/json", func(cache *specCache) []byte { return cache.swagger }))
+	g.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	})
+	g.GET("/docs/*asset", func(c *gin.Context) {
+		c.FileFromFS(c.Param("asset"), http.FS(assets))
+	})
+}
+
+func (r *Router) refreshSpecs(cache *specCache, config DocsConfig) error {
+	generation := r.generation.Load()
+	if cache.openapi != nil && cache.generation == generation {
+		return nil
+	}
+
+	sw := r.EmitOpenAPIDefinition()
+	applyInfo(sw.Info, config)
+	if len(config.Servers) > 0 {
+		if server, err := url.Parse(config.Servers[0].URL); err == nil {
+			sw.Host = server.Host
+			sw.BasePath = server.Path
+			if server.Scheme != "" {
+				sw.Schemes = []string{server.Scheme}
+			}
+		}
+	}
+	swagger, err := json.Marshal(sw)
+	if err != nil {
+		return err
+	}
+
+	doc := r.EmitOpenAPI31Definition()
+	applyInfo(doc.Info, config)
+	doc.Servers = config.Servers
+	openapiJSON, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	openapiYAML, err := jsonToYAML(openapiJSON)
+	if err != nil {
+		return err
+	}
+
+	cache.generation = generation
+	cache.swagger = swagger
+	cache.openapi = openapiJSON
+	cache.yaml = openapiYAML
+	return nil
+}
+
+func applyInfo(info *openapi.Info, config DocsConfig) {
+	if config.Title != "" {
+		info.Title = config.Title
+	}
+	if config.Version != "" {
+		info.Version = config.Version
+	}
+	info.Description = config.Description
+}
+
+// jsonToYAML re-encodes a JSON document as block style YAML, keeping the key
+// order of the input.
+func jsonToYAML(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var clearStyle func(*yaml.Node)
+	clearStyle = func(n *yaml.Node) {
+		n.Style = 0
+		for _, child := range n.Content {
+			clearStyle(child)
+		}
+	}
+	clearStyle(&doc)
+	return yaml.Marshal(&doc)
+}
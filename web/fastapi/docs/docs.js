@@ -0,0 +1,334 @@
+(function () {
+  "use strict";
+
+  var METHODS = ["get", "post", "put", "patch", "delete", "head", "options"];
+  var spec = null;
+
+  function el(tag, attrs, children) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (key) {
+      if (key === "text") {
+        node.textContent = attrs[key];
+      } else {
+        node.setAttribute(key, attrs[key]);
+      }
+    });
+    (children || []).forEach(function (child) {
+      if (child) {
+        node.appendChild(typeof child === "string" ? document.createTextNode(child) : child);
+      }
+    });
+    return node;
+  }
+
+  function resolve(schema) {
+    var seen = {};
+    while (schema && schema.$ref) {
+      if (seen[schema.$ref]) {
+        break;
+      }
+      seen[schema.$ref] = true;
+      var name = schema.$ref.split("/").pop();
+      schema = (spec.components.schemas || {})[name];
+    }
+    return schema || {};
+  }
+
+  function typeName(schema) {
+    if (!schema) {
+      return "";
+    }
+    if (schema.$ref) {
+      return schema.$ref.split("/").pop();
+    }
+    if (schema.anyOf) {
+      return schema.anyOf.map(typeName).join(" | ");
+    }
+    var type = [].concat(schema.type || "any").join(" | ");
+    if (type === "array") {
+      return "array<" + typeName(schema.items) + ">";
+    }
+    return schema.format ? type + " (" + schema.format + ")" : type;
+  }
+
+  function constraints(schema) {
+    var keys = ["minimum", "maximum", "minLength", "maxLength", "minItems", "maxItems", "pattern", "default"];
+    var parts = keys.filter(function (key) {
+      return schema[key] !== undefined;
+    }).map(function (key) {
+      return key + ": " + JSON.stringify(schema[key]);
+    });
+    if (schema.enum) {
+      parts.push("enum: " + schema.enum.map(function (v) { return JSON.stringify(v); }).join(", "));
+    }
+    return parts.join(", ");
+  }
+
+  // example builds a sample value for a schema, following references at
+  // most once per type so recursive schemas terminate.
+  function example(schema, depth) {
+    depth = depth || {};
+    if (schema && schema.$ref) {
+      if (depth[schema.$ref]) {
+        return null;
+      }
+      depth = Object.assign({}, depth);
+      depth[schema.$ref] = true;
+    }
+    if (schema && schema.anyOf) {
+      return example(schema.anyOf[0], depth);
+    }
+    schema = resolve(schema);
+    if (schema.example !== undefined) {
+      return schema.example;
+    }
+    if (schema.default !== undefined) {
+      return schema.default;
+    }
+    if (schema.enum) {
+      return schema.enum[0];
+    }
+    switch ([].concat(schema.type || "")[0]) {
+      case "object":
+        var value = {};
+        Object.keys(schema.properties || {}).forEach(function (key) {
+          value[key] = example(schema.properties[key], depth);
+        });
+        return value;
+      case "array":
+        return [example(schema.items, depth)];
+      case "integer":
+      case "number":
+        return schema.minimum || 0;
+      case "boolean":
+        return false;
+      case "string":
+        return schema.format === "date-time" ? new Date().toISOString() : "string";
+    }
+    return null;
+  }
+
+  function schemaTable(schema) {
+    var resolved = resolve(schema);
+    if (!resolved.properties) {
+      return el("p", {}, [el("code", { text: typeName(schema) })]);
+    }
+    var required = resolved.required || [];
+    var rows = Object.keys(resolved.properties).sort().map(function (name) {
+      var prop = resolved.properties[name];
+      return el("tr", {}, [
+        el("td", {}, [el("code", { text: name }), required.indexOf(name) >= 0 ? " *" : ""]),
+        el("td", { text: typeName(prop) }),
+        el("td", { class: "muted", text: [prop.description, constraints(prop)].filter(Boolean).join(" ") })
+      ]);
+    });
+    return el("table", {}, [
+      el("tr", {}, [el("th", { text: "Field" }), el("th", { text: "Type" }), el("th", { text: "Notes" })])
+    ].concat(rows));
+  }
+
+  function firstContent(content) {
+    var types = Object.keys(content || {});
+    return types.length ? { type: types[0], media: content[types[0]] } : null;
+  }
+
+  function renderOperation(path, method, op) {
+    var body = el("div", { class: "body" });
+    if (op.description) {
+      body.appendChild(el("p", { text: op.description }));
+    }
+
+    var inputs = {};
+    var params = op.parameters || [];
+    if (params.length) {
+      body.appendChild(el("h4", { text: "Parameters" }));
+      body.appendChild(el("table", {}, [
+        el("tr", {}, [el("th", { text: "Name" }), el("th", { text: "In" }), el("th", { text: "Type" }), el("th", { text: "Value" })])
+      ].concat(params.map(function (param) {
+        var input = el("input", { placeholder: param.required ? "required" : "" });
+        if (param.schema && param.schema.default !== undefined) {
+          input.value = param.schema.default;
+        }
+        inputs[param.in + ":" + param.name] = { param: param, input: input };
+        return el("tr", {}, [
+          el("td", {}, [el("code", { text: param.name }), param.required ? " *" : ""]),
+          el("td", { text: param.in }),
+          el("td", { text: typeName(param.schema) + (param.schema ? " " + constraints(param.schema) : "") }),
+          el("td", {}, [input])
+        ]);
+      }))));
+    }
+
+    var bodyInput = null;
+    var request = op.requestBody && firstContent(op.requestBody.content);
+    if (request) {
+      body.appendChild(el("h4", { text: "Request body (" + request.type + ")" }));
+      body.appendChild(schemaTable(request.media.schema));
+      if (request.type.indexOf("json") >= 0) {
+        bodyInput = el("textarea", { rows: 8 });
+        var sample = request.media.example !== undefined ? request.media.example : example(request.media.schema);
+        bodyInput.value = JSON.stringify(sample, null, 2);
+        body.appendChild(bodyInput);
+      } else if (request.type.indexOf("form") >= 0) {
+        bodyInput = { form: {} };
+        var properties = resolve(request.media.schema).properties || {};
+        Object.keys(properties).sort().forEach(function (name) {
+          var prop = properties[name];
+          var file = prop.format === "binary" || (prop.items && prop.items.format === "binary");
+          var input = el("input", file ? { type: "file" } : { placeholder: name });
+          if (file && prop.type === "array") {
+            input.multiple = true;
+          }
+          bodyInput.form[name] = { input: input, prop: prop };
+          body.appendChild(el("p", {}, [el("code", { text: name }), " ", input]));
+        });
+      }
+    }
+
+    body.appendChild(el("h4", { text: "Responses" }));
+    Object.keys(op.responses || {}).sort().forEach(function (status) {
+      var resp = op.responses[status];
+      var content = firstContent(resp.content);
+      body.appendChild(el("p", {}, [el("strong", { text: status }), " " + resp.description + (content ? " (" + content.type + ")" : "")]));
+      if (content && content.media.schema) {
+        body.appendChild(schemaTable(content.media.schema));
+      }
+    });
+
+    var output = el("pre", { class: "muted", text: "" });
+    var send = el("button", { type: "button", text: "Send request" });
+    send.addEventListener("click", function () {
+      tryIt(path, method, inputs, request, bodyInput, output);
+    });
+    body.appendChild(send);
+    body.appendChild(output);
+
+    return el("details", { class: "operation" + (op.deprecated ? " deprecated" : "") }, [
+      el("summary", {}, [
+        el("span", { class: "method " + method, text: method }),
+        el("span", { class: "path", text: path }),
+        op.summary ? el("span", { class: "muted", text: " " + op.summary }) : null
+      ]),
+      body
+    ]);
+  }
+
+  function tryIt(path, method, inputs, request, bodyInput, output) {
+    var url = path;
+    var query = [];
+    var headers = {};
+    Object.keys(inputs).forEach(function (key) {
+      var param = inputs[key].param;
+      var value = inputs[key].input.value;
+      if (value === "") {
+        return;
+      }
+      switch (param.in) {
+        case "path":
+          url = url.replace("{" + param.name + "}", encodeURIComponent(value));
+          break;
+        case "query":
+          value.split(",").forEach(function (item) {
+            query.push(encodeURIComponent(param.name) + "=" + encodeURIComponent(item.trim()));
+          });
+          break;
+        case "header":
+          headers[param.name] = value;
+          break;
+        case "cookie":
+          document.cookie = param.name + "=" + encodeURIComponent(value);
+          break;
+      }
+    });
+    if (query.length) {
+      url += "?" + query.join("&");
+    }
+
+    var init = { method: method.toUpperCase(), headers: headers, credentials: "same-origin" };
+    if (bodyInput && bodyInput.form) {
+      // The browser sets the multipart boundary itself.
+      init.body = new FormData();
+      Object.keys(bodyInput.form).forEach(function (name) {
+        var input = bodyInput.form[name].input;
+        if (input.type === "file") {
+          Array.prototype.forEach.call(input.files, function (file) {
+            init.body.append(name, file);
+          });
+        } else if (input.value !== "") {
+          var values = bodyInput.form[name].prop.type === "array" ? input.value.split(",") : [input.value];
+          values.forEach(function (item) {
+            init.body.append(name, item.trim());
+          });
+        }
+      });
+    } else if (bodyInput) {
+      headers["Content-Type"] = request.type;
+      init.body = bodyInput.value;
+    }
+
+    output.textContent = "…";
+    fetch(document.getElementById("servers").value + url, init).then(function (resp) {
+      return resp.text().then(function (text) {
+        try {
+          text = JSON.stringify(JSON.parse(text), null, 2);
+        } catch (e) {
+          // not JSON, show as is
+        }
+        output.textContent = resp.status + " " + resp.statusText + "\n\n" + text;
+      });
+    }).catch(function (err) {
+      output.textContent = String(err);
+    });
+  }
+
+  function render() {
+    document.title = spec.info.title;
+    document.getElementById("title").textContent = spec.info.title;
+    document.getElementById("version").textContent = spec.info.version || "";
+
+    var servers = document.getElementById("servers");
+    (spec.servers && spec.servers.length ? spec.servers : [{ url: "" }]).forEach(function (server) {
+      servers.appendChild(el("option", { value: server.url.replace(/\/$/, ""), text: server.url || "/" }));
+    });
+
+    var groups = {};
+    Object.keys(spec.paths || {}).sort().forEach(function (path) {
+      METHODS.forEach(function (method) {
+        var op = spec.paths[path][method];
+        if (!op) {
+          return;
+        }
+        (op.tags && op.tags.length ? op.tags : ["default"]).forEach(function (tag) {
+          (groups[tag] = groups[tag] || []).push(renderOperation(path, method, op));
+        });
+      });
+    });
+
+    var descriptions = {};
+    (spec.tags || []).forEach(function (tag) {
+      descriptions[tag.name] = tag.description;
+    });
+
+    var main = document.getElementById("operations");
+    main.textContent = "";
+    Object.keys(groups).sort().forEach(function (tag) {
+      main.appendChild(el("h2", { text: tag }));
+      if (descriptions[tag]) {
+        main.appendChild(el("p", { class: "muted", text: descriptions[tag] }));
+      }
+      groups[tag].forEach(function (node) {
+        main.appendChild(node);
+      });
+    });
+  }
+
+  fetch(document.body.getAttribute("data-spec")).then(function (resp) {
+    return resp.json();
+  }).then(function (loaded) {
+    spec = loaded;
+    spec.components = spec.components || {};
+    render();
+  }).catch(function (err) {
+    document.getElementById("operations").textContent = "Failed to load specification: " + err;
+  });
+})();
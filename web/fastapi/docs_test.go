@@ -0,0 +1,116 @@
+package fastapi_test
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"web/fastapi"
+)
+
+func docsEngine(r *fastapi.Router) *gin.Engine {
+	engine := gin.New()
+	r.MountDocs(engine, fastapi.DocsConfig{
+		Title:       "Pets",
+		Version:     "2.1",
+		Description: "Pets and their owners",
+		Servers:     []fastapi.OpenAPIServer{{URL: "https://pets.test/api"}},
+	})
+	return engine
+}
+
+func get(engine *gin.Engine, target string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	return recorder
+}
+
+func TestMountDocs(t *testing.T) {
+	engine := docsEngine(methodsRouter())
+	tests := []struct {
+		target      string
+		contentType string
+		body        string
+	}{
+		{"/openapi.json", "application/json", `"openapi":"3.1.0"`},
+		{"/openapi.yaml", "application/yaml", "openapi: 3.1.0"},
+		{"/swagger.json", "application/json", `"swagger":"2.0"`},
+		{"/docs", "text/html", `data-spec="openapi.json"`},
+		{"/docs/docs.js", "", "data-spec"},
+		{"/docs/docs.css", "text/css", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			recorder := get(engine, test.target)
+			expectStatus(t, recorder, http.StatusOK)
+			if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, test.contentType) {
+				t.Errorf("Content-Type %q, want %q", contentType, test.contentType)
+			}
+			if !strings.Contains(recorder.Body.String(), test.body) {
+				t.Errorf("body does not contain %q: %.200s", test.body, recorder.Body.String())
+			}
+		})
+	}
+	expectStatus(t, get(engine, "/docs/missing.js"), http.StatusNotFound)
+}
+
+func TestMountDocsConfig(t *testing.T) {
+	engine := docsEngine(methodsRouter())
+
+	var doc fastapi.OpenAPIDocument
+	if err := json.Unmarshal(get(engine, "/openapi.json").Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Info.Title != "Pets" || doc.Info.Version != "2.1" || doc.Info.Description != "Pets and their owners" {
+		t.Errorf("3.1 info %+v, want the configured one", doc.Info.InfoProps)
+	}
+	if len(doc.Servers) != 1 || doc.Servers[0].URL != "https://pets.test/api" {
+		t.Errorf("servers %+v, want the configured one", doc.Servers)
+	}
+
+	var sw map[string]interface{}
+	if err := json.Unmarshal(get(engine, "/swagger.json").Body.Bytes(), &sw); err != nil {
+		t.Fatal(err)
+	}
+	if sw["host"] != "pets.test" || sw["basePath"] != "/api" || at(sw, "schemes", 0) != "https" || at(sw, "info", "title") != "Pets" {
+		t.Errorf("swagger %v, want the configured host, base path, scheme and title", sw)
+	}
+
+	var fromYAML, fromJSON interface{}
+	if err := yaml.Unmarshal(get(engine, "/openapi.yaml").Body.Bytes(), &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal(get(engine, "/openapi.json").Body.Bytes(), &fromJSON)
+	if at(fromYAML, "paths", "/items/{id}", "get", "operationId") != at(fromJSON, "paths", "/items/{id}", "get", "operationId") {
+		t.Error("the YAML and JSON documents differ")
+	}
+}
+
+func TestMountDocsRefresh(t *testing.T) {
+	r := methodsRouter()
+	engine := docsEngine(r)
+	before := get(engine, "/openapi.json").Body.String()
+	if again := get(engine, "/openapi.json").Body.String(); again != before {
+		t.Error("the document changed without new routes")
+	}
+
+	r.Handle(http.MethodGet, "/owners", func(c *gin.Context, in struct{}) (owner, error) {
+		return owner{}, nil
+	})
+	for _, target := range []string{"/openapi.json", "/openapi.yaml", "/swagger.json"} {
+		if body := get(engine, target).Body.String(); !strings.Contains(body, "/owners") {
+			t.Errorf("%s does not describe a route added after serving it", target)
+		}
+	}
+
+	group := r.Group("/admin")
+	group.Handle(http.MethodGet, "/stats", func(c *gin.Context, in struct{}) (owner, error) {
+		return owner{}, nil
+	})
+	if body := get(engine, "/openapi.json").Body.String(); !strings.Contains(body, "/admin/stats") {
+		t.Error("the document does not describe a route added to a group")
+	}
+}
@@ -0,0 +1,130 @@
+package fastapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+const problemContentType = "application/problem+json"
+
+// HTTPError is implemented by errors that carry their own HTTP status.
+// Errors may additionally implement ErrorCode() string and
+// ErrorDetails() interface{} to fill the matching problem fields.
+type HTTPError interface {
+	error
+	HTTPStatus() int
+}
+
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details interface{}
+}
+
+func NewError(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Message)
+}
+
+func (e *Error) HTTPStatus() int {
+	return e.Status
+}
+
+func (e *Error) ErrorCode() string {
+	return e.Code
+}
+
+func (e *Error) ErrorDetails() interface{} {
+	return e.Details
+}
+
+// WithDetails returns a copy of the error carrying details, so that
+// package-level sentinel errors can be reused safely.
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// Is makes errors.Is match any *Error with the same status and code.
+func (e *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+	return ok && other.Status == e.Status && other.Code == e.Code
+}
+
+func (e *ValidationError) HTTPStatus() int {
+	return http.StatusUnprocessableEntity
+}
+
+func (e *ValidationError) ErrorCode() string {
+	return "validation_failed"
+}
+
+func (e *ValidationError) ErrorDetails() interface{} {
+	return e.Errors
+}
+
+// Problem is the RFC 7807 body written for every error response.
+type Problem struct {
+	Type    string      `json:"type"`
+	Title   string      `json:"title"`
+	Status  int         `json:"status"`
+	Detail  string      `json:"detail,omitempty"`
+	Code    string      `json:"code,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+func problemFromError(err error) Problem {
+	if tooLarge := bodyTooLarge(err); tooLarge != nil {
+		err = tooLarge
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		err = errTimeout
+	}
+	var httpErr HTTPError
+	if !errors.As(err, &httpErr) {
+		log.Printf("fastapi: unhandled error: %v", err)
+		return Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Status: http.StatusInternalServerError,
+			Code:   "internal_error",
+		}
+	}
+
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(httpErr.HTTPStatus()),
+		Status: httpErr.HTTPStatus(),
+	}
+	if coded, ok := httpErr.(interface{ ErrorCode() string }); ok {
+		problem.Code = coded.ErrorCode()
+	}
+	if detailed, ok := httpErr.(interface{ ErrorDetails() interface{} }); ok {
+		problem.Details = detailed.ErrorDetails()
+	}
+	problem.Detail = httpErr.Error()
+	if typed, ok := httpErr.(*Error); ok {
+		problem.Detail = typed.Message
+	} else if _, ok := httpErr.(*ValidationError); ok {
+		problem.Detail = "validation failed"
+	}
+	return problem
+}
+
+func writeError(c *gin.Context, err error) {
+	problem := problemFromError(err)
+	if c.Writer.Written() {
+		log.Printf("fastapi: response already written, dropping error: %v", err)
+		return
+	}
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
@@ -0,0 +1,108 @@
+package fastapi_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"web/fastapi"
+)
+
+var errNotFound = fastapi.NewError(http.StatusNotFound, "item_not_found", "no such item")
+
+// teapotError implements HTTPError and ErrorCode, but not ErrorDetails.
+type teapotError struct{}
+
+func (teapotError) Error() string     { return "short and stout" }
+func (teapotError) HTTPStatus() int   { return http.StatusTeapot }
+func (teapotError) ErrorCode() string { return "teapot" }
+
+type failInput struct {
+	Kind string `query:"kind"`
+}
+
+func errorsRouter() *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodGet, "/fail", func(c *gin.Context, in failInput) (item, error) {
+		switch in.Kind {
+		case "typed":
+			return item{}, errNotFound.WithDetails(map[string]string{"id": "7"})
+		case "wrapped":
+			return item{}, fmt.Errorf("loading: %w", errNotFound)
+		case "custom":
+			return item{}, teapotError{}
+		default:
+			return item{}, errors.New("database password is hunter2")
+		}
+	}, fastapi.Errors(errNotFound, teapotError{}))
+	return r
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		kind string
+		want fastapi.Problem
+	}{
+		{"typed", fastapi.Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "no such item", Code: "item_not_found", Details: map[string]interface{}{"id": "7"}}},
+		{"wrapped", fastapi.Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "no such item", Code: "item_not_found"}},
+		{"custom", fastapi.Problem{Type: "about:blank", Title: "I'm a teapot", Status: http.StatusTeapot, Detail: "short and stout", Code: "teapot"}},
+		{"plain", fastapi.Problem{Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError, Code: "internal_error"}},
+	}
+	r := errorsRouter()
+	for _, test := range tests {
+		t.Run(test.kind, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodGet, "/fail?kind="+test.kind, "", nil)
+			expectStatus(t, recorder, test.want.Status)
+			if contentType := recorder.Header().Get("Content-Type"); contentType != "application/problem+json" {
+				t.Errorf("Content-Type %q, want application/problem+json", contentType)
+			}
+			if got := problem(t, recorder); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("problem %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	detailed := errNotFound.WithDetails("7")
+	if !errors.Is(detailed, errNotFound) || !errors.Is(fmt.Errorf("wrapped: %w", detailed), errNotFound) {
+		t.Error("errors.Is does not match an error with the same status and code")
+	}
+	if errors.Is(detailed, fastapi.NewError(http.StatusNotFound, "other", "no such item")) {
+		t.Error("errors.Is matches an error with another code")
+	}
+	if errNotFound.Details != nil {
+		t.Error("WithDetails changed the sentinel error")
+	}
+}
+
+func TestErrorsSpec(t *testing.T) {
+	op := errorsRouter().EmitOpenAPIDefinition().Paths.Paths["/fail"].Get
+	tests := []struct {
+		status      int
+		description string
+	}{
+		{http.StatusNotFound, "`item_not_found`: no such item"},
+		{http.StatusTeapot, "short and stout"},
+		{http.StatusUnprocessableEntity, "Validation failed"},
+	}
+	for _, test := range tests {
+		resp, present := op.Responses.StatusCodeResponses[test.status]
+		if !present {
+			t.Errorf("%d is not documented", test.status)
+			continue
+		}
+		if resp.Description != test.description {
+			t.Errorf("%d described %q, want %q", test.status, resp.Description, test.description)
+		}
+		if resp.Schema == nil || !strings.HasSuffix(resp.Schema.Ref.String(), "Problem") {
+			t.Errorf("%d schema %v, want the problem definition", test.status, resp.Schema)
+		}
+	}
+	if produces := op.Produces; len(produces) == 0 || produces[len(produces)-1] != "application/problem+json" {
+		t.Errorf("produces %v, want problem+json", produces)
+	}
+}
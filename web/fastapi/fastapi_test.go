@@ -0,0 +1,65 @@
+package fastapi_test
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"web/fastapi"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// serve sends a request to handler, the GinHandler of a router, mounted
+// like services mount it.
+func serve(handler gin.HandlerFunc, method, target string, body string, header http.Header) *httptest.ResponseRecorder {
+	engine := gin.New()
+	engine.Any("/*path", handler)
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// decode decodes the {"response": ...} envelope of a response into out.
+func decode(t *testing.T, recorder *httptest.ResponseRecorder, out interface{}) {
+	t.Helper()
+	envelope := struct {
+		Response interface{} `json:"response"`
+	}{out}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decoding %q: %v", recorder.Body.String(), err)
+	}
+}
+
+// problem decodes an error response.
+func problem(t *testing.T, recorder *httptest.ResponseRecorder) fastapi.Problem {
+	t.Helper()
+	var problem fastapi.Problem
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decoding %q: %v", recorder.Body.String(), err)
+	}
+	return problem
+}
+
+func expectStatus(t *testing.T, recorder *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if recorder.Code != status {
+		t.Fatalf("status %d, want %d: %s", recorder.Code, status, recorder.Body.String())
+	}
+}
@@ -0,0 +1,78 @@
+// Package fastapitest drives a fastapi.Router in process for tests, with
+// typed inputs and outputs, and checks every response against the router's
+// spec.
+package fastapitest
+
+import (
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"web/fastapi"
+)
+
+// Client calls a router without a server. Inputs are encoded and outputs
+// decoded like with clients generated by GenerateClient, and errors are
+// *fastapi.Error. Responses departing from the router's spec fail the test.
+type Client struct {
+	*fastapi.Client
+	t testing.TB
+}
+
+// NewClient returns a client of r. Requests are not retried unless opts say
+// otherwise.
+func NewClient(t testing.TB, r *fastapi.Router, opts ...fastapi.ClientOption) *Client {
+	engine := gin.New()
+	engine.Any("/*path", r.GinHandler)
+	transport := &transport{t: t, router: r, handler: engine}
+	opts = append([]fastapi.ClientOption{
+		fastapi.WithHTTPClient(&http.Client{Transport: transport}),
+		fastapi.WithRetries(0, 0),
+	}, opts...)
+	return &Client{Client: fastapi.NewClient("http://fastapi.test", opts...), t: t}
+}
+
+// Call calls the route at method and path, a pattern such as "/users/{id}",
+// with in and decodes its response into Out.
+func Call[Out any](c *Client, method, path string, in interface{}) (Out, error) {
+	var out Out
+	err := c.Do(context.Background(), method, path, in, &out)
+	return out, err
+}
+
+// MustCall is Call failing the test on errors.
+func MustCall[Out any](c *Client, method, path string, in interface{}) Out {
+	c.t.Helper()
+	out, err := Call[Out](c, method, path, in)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	return out
+}
+
+// transport serves requests with the router and checks the responses.
+type transport struct {
+	t       testing.TB
+	router  *fastapi.Router
+	handler http.Handler
+}
+
+func (tr *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	tr.handler.ServeHTTP(recorder, req)
+	resp := recorder.Result()
+	resp.Request = req
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err := tr.router.CheckResponse(req.Method, req.URL.Path, resp.StatusCode, resp.Header, body); err != nil {
+		tr.t.Errorf("fastapitest: %v", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,119 @@
+package fastapitest_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"web/fastapi"
+	"web/fastapi/fastapitest"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+type Greeting struct {
+	Text string `json:"text"`
+}
+
+type GreetInput struct {
+	Name     string `path:"name"`
+	Greeting string `header:"X-Greeting" default:"hello"`
+	Calls    int    `query:"calls" default:"0"`
+}
+
+var (
+	errUnknown     = fastapi.NewError(http.StatusNotFound, "unknown_name", "unknown name")
+	errUnavailable = fastapi.NewError(http.StatusServiceUnavailable, "unavailable", "try again")
+)
+
+func greeter() *fastapi.Router {
+	calls := 0
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodGet, "/greet/{name}", func(c *gin.Context, in GreetInput) (Greeting, error) {
+		calls++
+		switch {
+		case in.Name == "nobody":
+			return Greeting{}, errUnknown
+		case calls <= in.Calls:
+			return Greeting{}, errUnavailable
+		}
+		return Greeting{Text: in.Greeting + " " + in.Name}, nil
+	}, fastapi.Errors(errUnknown, errUnavailable))
+	r.Handle(http.MethodGet, "/drift", func(c *gin.Context, in struct{}) (Greeting, error) {
+		c.JSON(http.StatusOK, gin.H{"response": gin.H{"text": 1}})
+		return Greeting{}, nil
+	})
+	return r
+}
+
+// recorder records the failures of the client instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func TestCall(t *testing.T) {
+	client := fastapitest.NewClient(t, greeter(), fastapi.WithHeader("X-Greeting", "hi"))
+	got := fastapitest.MustCall[Greeting](client, http.MethodGet, "/greet/{name}", GreetInput{Name: "ann"})
+	if got.Text != "hi ann" {
+		t.Errorf("greeted %q, want hi ann", got.Text)
+	}
+
+	_, err := fastapitest.Call[Greeting](client, http.MethodGet, "/greet/{name}", GreetInput{Name: "nobody"})
+	var apiErr *fastapi.Error
+	if !errors.As(err, &apiErr) || !errors.Is(err, errUnknown) || apiErr.Message != "unknown name" {
+		t.Errorf("got %v, want %v", err, errUnknown)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	_, err := fastapitest.Call[Greeting](fastapitest.NewClient(t, greeter()), http.MethodGet, "/greet/{name}", GreetInput{Name: "ann", Calls: 1})
+	if err == nil {
+		t.Error("retried by default")
+	}
+
+	client := fastapitest.NewClient(t, greeter(), fastapi.WithRetries(1, 0))
+	if _, err := fastapitest.Call[Greeting](client, http.MethodGet, "/greet/{name}", GreetInput{Name: "ann", Calls: 1}); err != nil {
+		t.Errorf("got %v with a retry", err)
+	}
+}
+
+func TestMustCallFails(t *testing.T) {
+	rec := &recorder{TB: t}
+	fastapitest.MustCall[Greeting](fastapitest.NewClient(rec, greeter()), http.MethodGet, "/greet/{name}", GreetInput{Name: "nobody"})
+	if !rec.fatal || len(rec.errors) != 1 || !strings.Contains(rec.errors[0], "GET /greet/{name}") {
+		t.Errorf("failed with %q, want a fatal error naming the route", rec.errors)
+	}
+}
+
+func TestContractChecked(t *testing.T) {
+	rec := &recorder{TB: t}
+	fastapitest.Call[Greeting](fastapitest.NewClient(rec, greeter()), http.MethodGet, "/drift", struct{}{})
+	if rec.fatal || len(rec.errors) != 1 || !strings.HasPrefix(rec.errors[0], "fastapitest: ") {
+		t.Errorf("failed with %q, want the response reported as off the spec", rec.errors)
+	}
+
+	rec = &recorder{TB: t}
+	fastapitest.Call[Greeting](fastapitest.NewClient(rec, greeter()), http.MethodGet, "/greet/{name}", GreetInput{Name: "nobody"})
+	if len(rec.errors) != 0 {
+		t.Errorf("failed with %q for a documented error", rec.errors)
+	}
+}
@@ -0,0 +1,152 @@
+package fastapi
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	openapi "github.com/go-openapi/spec"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// multipartMemory is how much of a multipart body is held in memory, the
+// rest of the uploaded files is stored in temporary files.
+const multipartMemory = 32 << 20
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+func isFileField(fieldType reflect.Type) bool {
+	return fieldType == fileHeaderType || fieldType == fileHeadersType
+}
+
+// hasFormFields reports whether the input is read from a form: it has
+// fields tagged `form:"name"`, which may be *multipart.FileHeader or
+// []*multipart.FileHeader for uploaded files.
+func hasFormFields(inputType reflect.Type) bool {
+	found := false
+	eachTaggedField(reflect.New(inputType).Elem(), "form", func(string, reflect.Value, reflect.StructField) error {
+		found = true
+		return nil
+	})
+	return found
+}
+
+func hasFileFields(inputType reflect.Type) bool {
+	found := false
+	eachTaggedField(reflect.New(inputType).Elem(), "form", func(_ string, field reflect.Value, _ reflect.StructField) error {
+		found = found || isFileField(field.Type())
+		return nil
+	})
+	return found
+}
+
+func checkFormFields(method string, inputType reflect.Type) {
+	if !hasFormFields(inputType) {
+		return
+	}
+	if !hasRequestBody(method) {
+		panic("Form fields need a request body, " + method + " has none")
+	}
+	if hasBodyFields(inputType) {
+		panic("Input cannot mix form fields and JSON body fields")
+	}
+}
+
+// formMediaTypes are the request media types of a route reading a form.
+func formMediaTypes(inputType reflect.Type) []string {
+	if hasFileFields(inputType) {
+		return []string{binding.MIMEMultipartPOSTForm}
+	}
+	return []string{binding.MIMEMultipartPOSTForm, binding.MIMEPOSTForm}
+}
+
+func parseForm(c *gin.Context) error {
+	var err error
+	if c.ContentType() == binding.MIMEMultipartPOSTForm {
+		err = c.Request.ParseMultipartForm(multipartMemory)
+	} else {
+		err = c.Request.ParseForm()
+	}
+	if tooLarge := bodyTooLarge(err); tooLarge != nil {
+		return tooLarge
+	}
+	if err != nil {
+		return NewError(http.StatusBadRequest, "invalid_request", "invalid form: "+err.Error())
+	}
+	return nil
+}
+
+func formFiles(c *gin.Context, name string) []*multipart.FileHeader {
+	if c.Request.MultipartForm == nil {
+		return nil
+	}
+	return c.Request.MultipartForm.File[name]
+}
+
+// Stream is an output written from Reader instead of being encoded. When
+// Reader is an io.ReadSeeker, such as an *os.File, Range and conditional
+// requests are answered by http.ServeContent. Readers that are io.Closers
+// are closed once written.
+type Stream struct {
+	Reader      io.Reader
+	ContentType string
+	// Filename, when set, is sent in the Content-Disposition header as an
+	// attachment, or inline when Inline is set.
+	Filename string
+	Inline   bool
+	// Size is sent as Content-Length for readers that cannot seek.
+	Size    int64
+	ModTime time.Time
+}
+
+var streamType = reflect.TypeOf(Stream{})
+
+func (s Stream) write(c *gin.Context, status int) error {
+	header := c.Writer.Header()
+	if s.ContentType != "" {
+		header.Set("Content-Type", s.ContentType)
+	}
+	if s.Filename != "" {
+		disposition := "attachment"
+		if s.Inline {
+			disposition = "inline"
+		}
+		header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": s.Filename}))
+	}
+	if closer, ok := s.Reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if seeker, ok := s.Reader.(io.ReadSeeker); ok && status == http.StatusOK {
+		http.ServeContent(c.Writer, c.Request, s.Filename, s.ModTime, seeker)
+		return nil
+	}
+
+	if s.ContentType == "" {
+		header.Set("Content-Type", "application/octet-stream")
+	}
+	if s.Size > 0 {
+		header.Set("Content-Length", strconv.FormatInt(s.Size, 10))
+	}
+	header.Set("Accept-Ranges", "none")
+	c.Status(status)
+	c.Writer.WriteHeaderNow()
+	if s.Reader == nil || c.Request.Method == http.MethodHead {
+		return nil
+	}
+	_, err := io.Copy(c.Writer, s.Reader)
+	return err
+}
+
+// fileSchema describes uploaded and streamed files in Swagger 2.0 terms;
+// schema31 turns it into a binary string.
+func fileSchema() openapi.Schema {
+	return *new(openapi.Schema).Typed("file", "")
+}
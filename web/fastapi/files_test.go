@@ -0,0 +1,217 @@
+package fastapi_test
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"web/fastapi"
+)
+
+type uploadInput struct {
+	Title       string                  `form:"title" validate:"required"`
+	Copies      int                     `form:"copies" default:"1"`
+	File        *multipart.FileHeader   `form:"file" validate:"required"`
+	Attachments []*multipart.FileHeader `form:"attachments"`
+}
+
+type upload struct {
+	Title       string   `json:"title"`
+	Copies      int      `json:"copies"`
+	Content     string   `json:"content"`
+	Attachments []string `json:"attachments"`
+}
+
+type downloadInput struct {
+	Seekable bool `query:"seekable" default:"true"`
+}
+
+const report = "0123456789abcdefghij"
+
+var reportModified = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func filesRouter() *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodPost, "/uploads", func(c *gin.Context, in uploadInput) (upload, error) {
+		file, err := in.File.Open()
+		if err != nil {
+			return upload{}, err
+		}
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		if err != nil {
+			return upload{}, err
+		}
+		out := upload{Title: in.Title, Copies: in.Copies, Content: string(content)}
+		for _, attachment := range in.Attachments {
+			out.Attachments = append(out.Attachments, attachment.Filename)
+		}
+		return out, nil
+	})
+	r.Handle(http.MethodGet, "/report", func(c *gin.Context, in downloadInput) (fastapi.Stream, error) {
+		stream := fastapi.Stream{ContentType: "text/plain", Filename: "report.txt", ModTime: reportModified}
+		if in.Seekable {
+			stream.Reader = strings.NewReader(report)
+		} else {
+			stream.Reader = io.MultiReader(strings.NewReader(report))
+			stream.Size = int64(len(report))
+		}
+		return stream, nil
+	})
+	return r
+}
+
+// multipartBody encodes fields and files, given as name, filename and
+// content, as a multipart form.
+func multipartBody(fields map[string]string, files ...[3]string) (string, http.Header) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	for _, file := range files {
+		part, _ := writer.CreateFormFile(file[0], file[1])
+		part.Write([]byte(file[2]))
+	}
+	writer.Close()
+	return body.String(), http.Header{"Content-Type": {writer.FormDataContentType()}}
+}
+
+func TestUpload(t *testing.T) {
+	r := filesRouter()
+	body, header := multipartBody(map[string]string{"title": "Q3", "copies": "2"},
+		[3]string{"file", "q3.csv", "a,b"},
+		[3]string{"attachments", "one.png", "1"},
+		[3]string{"attachments", "two.png", "2"})
+	recorder := serve(r.GinHandler, http.MethodPost, "/uploads", body, header)
+	expectStatus(t, recorder, http.StatusOK)
+	var got upload
+	decode(t, recorder, &got)
+	if got.Title != "Q3" || got.Copies != 2 || got.Content != "a,b" || strings.Join(got.Attachments, " ") != "one.png two.png" {
+		t.Errorf("uploaded %+v", got)
+	}
+
+	body, header = multipartBody(map[string]string{"title": "Q3"}, [3]string{"file", "q3.csv", ""})
+	recorder = serve(r.GinHandler, http.MethodPost, "/uploads", body, header)
+	expectStatus(t, recorder, http.StatusOK)
+	got = upload{}
+	decode(t, recorder, &got)
+	if got.Copies != 1 || got.Attachments != nil {
+		t.Errorf("uploaded %+v, want the default copies and no attachments", got)
+	}
+}
+
+func TestUploadInvalid(t *testing.T) {
+	r := filesRouter()
+	withoutFile, withoutFileHeader := multipartBody(map[string]string{"title": "Q3"})
+	tests := []struct {
+		name   string
+		body   string
+		header http.Header
+		status int
+		code   string
+	}{
+		{"missing file", withoutFile, withoutFileHeader, http.StatusUnprocessableEntity, "validation_failed"},
+		{"url encoded", "title=Q3", http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}, http.StatusUnprocessableEntity, "validation_failed"},
+		{"no boundary", withoutFile, http.Header{"Content-Type": {"multipart/form-data"}}, http.StatusBadRequest, "invalid_request"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodPost, "/uploads", test.body, test.header)
+			expectStatus(t, recorder, test.status)
+			if code := problem(t, recorder).Code; code != test.code {
+				t.Errorf("code %q, want %q", code, test.code)
+			}
+		})
+	}
+}
+
+func TestDownload(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+		status int
+		body   string
+		want   http.Header
+	}{
+		{"whole", "/report", nil, http.StatusOK, report, http.Header{
+			"Content-Type":        {"text/plain"},
+			"Content-Disposition": {`attachment; filename=report.txt`},
+			"Accept-Ranges":       {"bytes"},
+			"Content-Length":      {"20"},
+			"Last-Modified":       {"Tue, 02 Jan 2024 03:04:05 GMT"},
+		}},
+		{"range", "/report", http.Header{"Range": {"bytes=5-9"}}, http.StatusPartialContent, "56789", http.Header{
+			"Content-Range":  {"bytes 5-9/20"},
+			"Content-Length": {"5"},
+		}},
+		{"suffix range", "/report", http.Header{"Range": {"bytes=-3"}}, http.StatusPartialContent, "hij", http.Header{
+			"Content-Range": {"bytes 17-19/20"},
+		}},
+		{"unsatisfiable range", "/report", http.Header{"Range": {"bytes=50-60"}}, http.StatusRequestedRangeNotSatisfiable, "", http.Header{
+			"Content-Range": {"bytes */20"},
+		}},
+		{"not modified", "/report", http.Header{"If-Modified-Since": {"Tue, 02 Jan 2024 03:04:05 GMT"}}, http.StatusNotModified, "", nil},
+		{"not seekable", "/report?seekable=false", http.Header{"Range": {"bytes=5-9"}}, http.StatusOK, report, http.Header{
+			"Accept-Ranges":  {"none"},
+			"Content-Length": {"20"},
+		}},
+	}
+	r := filesRouter()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodGet, test.target, "", test.header)
+			expectStatus(t, recorder, test.status)
+			if test.body != "" && recorder.Body.String() != test.body {
+				t.Errorf("body %q, want %q", recorder.Body.String(), test.body)
+			}
+			for name := range test.want {
+				if got := recorder.Header().Get(name); got != test.want.Get(name) {
+					t.Errorf("%s %q, want %q", name, got, test.want.Get(name))
+				}
+			}
+		})
+	}
+}
+
+func TestFilesSpec(t *testing.T) {
+	paths := filesRouter().EmitOpenAPIDefinition().Paths.Paths
+	uploads := paths["/uploads"].Post
+	if len(uploads.Consumes) != 1 || uploads.Consumes[0] != "multipart/form-data" {
+		t.Errorf("uploads consume %v, want multipart/form-data", uploads.Consumes)
+	}
+	types := make(map[string]string)
+	for _, param := range uploads.Parameters {
+		if param.In != "formData" {
+			t.Errorf("%s is in %s, want formData", param.Name, param.In)
+		}
+		types[param.Name] = param.Type
+	}
+	if types["title"] != "string" || types["copies"] != "integer" || types["file"] != "file" {
+		t.Errorf("form parameter types %v", types)
+	}
+
+	report := paths["/report"].Get
+	if len(report.Produces) == 0 || report.Produces[0] != "application/octet-stream" {
+		t.Errorf("report produces %v, want application/octet-stream", report.Produces)
+	}
+	if schema := report.Responses.StatusCodeResponses[http.StatusOK].Schema; schema == nil || !schema.Type.Contains("file") {
+		t.Errorf("report schema %v, want a file", schema)
+	}
+}
+
+func TestFormFieldsPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registered a GET route reading a form")
+		}
+	}()
+	fastapi.NewRouter().Handle(http.MethodGet, "/uploads", func(c *gin.Context, in uploadInput) (upload, error) {
+		return upload{}, nil
+	})
+}
@@ -0,0 +1,47 @@
+package fastapi
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+)
+
+// HandlerFunc is the typed handler signature. In is bound from the request
+// (body, path, query, header and cookie tags) and Out is written as the
+// response.
+type HandlerFunc[In, Out any] func(*gin.Context, In) (Out, error)
+
+// HandleFunc registers a typed handler. Unlike Handle the signature is
+// checked by the compiler and requests are dispatched without reflection.
+func HandleFunc[In, Out any](r *Router, method, path string, handler HandlerFunc[In, Out], opts ...RouteOption) {
+	invoke := func(c *gin.Context, inputPtr interface{}) (interface{}, error) {
+		return handler(c, *inputPtr.(*In))
+	}
+	r.addRoute(&route{
+		method:     method,
+		path:       path,
+		inputType:  reflect.TypeOf((*In)(nil)).Elem(),
+		outputType: reflect.TypeOf((*Out)(nil)).Elem(),
+		invoke:     invoke,
+	}, opts)
+}
+
+func Get[In, Out any](r *Router, path string, handler HandlerFunc[In, Out], opts ...RouteOption) {
+	HandleFunc(r, http.MethodGet, path, handler, opts...)
+}
+
+func Post[In, Out any](r *Router, path string, handler HandlerFunc[In, Out], opts ...RouteOption) {
+	HandleFunc(r, http.MethodPost, path, handler, opts...)
+}
+
+func Put[In, Out any](r *Router, path string, handler HandlerFunc[In, Out], opts ...RouteOption) {
+	HandleFunc(r, http.MethodPut, path, handler, opts...)
+}
+
+func Patch[In, Out any](r *Router, path string, handler HandlerFunc[In, Out], opts ...RouteOption) {
+	HandleFunc(r, http.MethodPatch, path, handler, opts...)
+}
+
+func Delete[In, Out any](r *Router, path string, handler HandlerFunc[In, Out], opts ...RouteOption) {
+	HandleFunc(r, http.MethodDelete, path, handler, opts...)
+}
@@ -0,0 +1,129 @@
+package fastapi_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"testing"
+	"web/fastapi"
+)
+
+// tracing returns middleware recording name in trace.
+func tracing(trace *[]string, name string) fastapi.Middleware {
+	return func(c *gin.Context, next func() error) error {
+		*trace = append(*trace, name)
+		return next()
+	}
+}
+
+func groupsRouter(trace *[]string) *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Use(tracing(trace, "root"))
+	r.DescribeTag("users", "People using the API")
+	r.DescribeTag("unused", "Listed even without routes")
+	r.Handle(http.MethodGet, "/health", func(c *gin.Context, in struct{}) (item, error) {
+		return item{}, nil
+	})
+
+	users := r.Group("/v1/users", "users")
+	users.Use(tracing(trace, "users"))
+	users.Defaults(fastapi.Tags("people"), fastapi.Summary("A user route"))
+	users.Handle(http.MethodGet, "/{id}", func(c *gin.Context, in itemInput) (item, error) {
+		return item{ID: in.ID}, nil
+	}, fastapi.Summary("Get a user"), fastapi.Description("Returns the user with the given id."),
+		fastapi.OperationID("getUser"), fastapi.ResponseExample(item{ID: "7"}))
+
+	admins := users.Group("admins", "admin")
+	admins.Use(tracing(trace, "admins"))
+	admins.Handle(http.MethodPost, "/{id}", func(c *gin.Context, in itemInput) (item, error) {
+		*trace = append(*trace, "handler")
+		return item{ID: in.ID}, nil
+	}, fastapi.Tags("extra"), fastapi.Deprecated(), fastapi.WithMiddleware(tracing(trace, "route")))
+	return r
+}
+
+func TestGroups(t *testing.T) {
+	var trace []string
+	r := groupsRouter(&trace)
+	recorder := serve(r.GinHandler, http.MethodPost, "/v1/users/admins/7", "", nil)
+	expectStatus(t, recorder, http.StatusOK)
+	var got item
+	decode(t, recorder, &got)
+	if got.ID != "7" {
+		t.Errorf("served %+v, want the id of the path", got)
+	}
+	if want := []string{"root", "users", "admins", "route", "handler"}; !reflect.DeepEqual(trace, want) {
+		t.Errorf("ran %v, want %v", trace, want)
+	}
+
+	// Group middleware does not run for the parent's routes.
+	trace = nil
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/health", "", nil), http.StatusOK)
+	if want := []string{"root"}; !reflect.DeepEqual(trace, want) {
+		t.Errorf("ran %v, want %v", trace, want)
+	}
+	expectStatus(t, serve(r.GinHandler, http.MethodPost, "/admins/7", "", nil), http.StatusNotFound)
+}
+
+func TestGroupsSpec(t *testing.T) {
+	var trace []string
+	spec := groupsRouter(&trace).EmitOpenAPIDefinition()
+	get := spec.Paths.Paths["/v1/users/{id}"].Get
+	post := spec.Paths.Paths["/v1/users/admins/{id}"].Post
+	if get == nil || post == nil {
+		t.Fatalf("paths %v, want the group prefixes", spec.Paths.Paths)
+	}
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"tags", get.Tags, []string{"users", "people"}},
+		{"nested tags", post.Tags, []string{"users", "people", "admin", "extra"}},
+		{"summary", get.Summary, "Get a user"},
+		{"default summary", post.Summary, "A user route"},
+		{"description", get.Description, "Returns the user with the given id."},
+		{"operationId", get.ID, "getUser"},
+		{"derived operationId", post.ID, "postV1UsersAdminsById"},
+		{"deprecated", post.Deprecated, true},
+		{"not deprecated", get.Deprecated, false},
+		{"response example", get.Responses.StatusCodeResponses[http.StatusOK].Examples["application/json"], gin.H{"response": item{ID: "7"}}},
+		{"untagged", spec.Paths.Paths["/health"].Get.Tags, []string(nil)},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("%s: %#v, want %#v", test.name, test.got, test.want)
+		}
+	}
+
+	var tags []string
+	for _, tag := range spec.Tags {
+		tags = append(tags, tag.Name)
+		if tag.Name == "users" && tag.Description != "People using the API" {
+			t.Errorf("users described %q", tag.Description)
+		}
+	}
+	if want := []string{"users", "people", "admin", "extra", "unused"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags %v, want %v", tags, want)
+	}
+}
+
+func TestOperationIDs(t *testing.T) {
+	r := fastapi.NewRouter()
+	handler := func(c *gin.Context, in struct{}) (item, error) { return item{}, nil }
+	r.Handle(http.MethodGet, "/user-list", handler)
+	r.Handle(http.MethodGet, "/user_list", handler)
+	r.Handle(http.MethodGet, "/userList", handler, fastapi.OperationID("getUserList"))
+	paths := r.EmitOpenAPIDefinition().Paths.Paths
+	ids := []string{paths["/user-list"].Get.ID, paths["/user_list"].Get.ID, paths["/userList"].Get.ID}
+	if want := []string{"getUserList2", "getUserList3", "getUserList"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("operationIds %v, want %v", ids, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registered a duplicate operationId")
+		}
+	}()
+	r.Group("/v2").Handle(http.MethodGet, "/users", handler, fastapi.OperationID("getUserList"))
+}
@@ -0,0 +1,201 @@
+package fastapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Timeout bounds the time taken to answer the route, middleware included.
+// The request's context is canceled at the deadline and the client answered
+// 504; whatever the handler writes afterwards is dropped. Handlers still
+// running then keep a copy of the gin context, so they must not rely on
+// changes to it being seen by the router. Event stream and WebSocket routes
+// are not bounded.
+func Timeout(timeout time.Duration) RouteOption {
+	return func(rt *route) {
+		rt.timeout = timeout
+	}
+}
+
+// MaxBodySize rejects request bodies over limit bytes with 413, e.g. for
+// every route with r.Defaults(MaxBodySize(1 << 20)).
+func MaxBodySize(limit int64) RouteOption {
+	return func(rt *route) {
+		rt.maxBodySize = limit
+	}
+}
+
+var errTimeout = NewError(http.StatusGatewayTimeout, "timeout", "request timed out")
+
+// limitBody applies the route's MaxBodySize, rejecting requests declaring a
+// longer body at once.
+func limitBody(c *gin.Context, rt *route) error {
+	if rt.maxBodySize <= 0 {
+		return nil
+	}
+	if c.Request.ContentLength > rt.maxBodySize {
+		return bodyTooLarge(&http.MaxBytesError{Limit: rt.maxBodySize})
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, rt.maxBodySize)
+	return nil
+}
+
+// bodyTooLarge returns the error answering a body cut by MaxBodySize, nil
+// for other errors.
+func bodyTooLarge(err error) error {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return nil
+	}
+	return NewError(http.StatusRequestEntityTooLarge, "body_too_large", fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+}
+
+// recovered turns a panic of serve into a 500 response, logging its stack.
+func recovered(rt *route, serve func(c *gin.Context)) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		defer func() {
+			if value := recover(); value != nil {
+				log.Printf("fastapi: panic serving %s %s: %v\n%s", rt.method, rt.path, value, debug.Stack())
+				writeError(c, NewError(http.StatusInternalServerError, "internal_error", "internal error"))
+			}
+		}()
+		serve(c)
+	}
+}
+
+// timedOut runs serve with the route's timeout, on a copy of the context
+// whose response is buffered until serve returns in time.
+func timedOut(rt *route, serve func(c *gin.Context)) func(c *gin.Context) {
+	if rt.timeout <= 0 || rt.events != nil || rt.inbound != nil {
+		return serve
+	}
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), rt.timeout)
+		defer cancel()
+		writer := &timeoutWriter{ResponseWriter: c.Writer, header: c.Writer.Header().Clone()}
+		bounded := c.Copy()
+		bounded.Request = c.Request.WithContext(ctx)
+		bounded.Writer = writer
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			serve(bounded)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			writer.mu.Lock()
+			select {
+			case <-done:
+			default:
+				writer.timedOut = true
+			}
+			writer.mu.Unlock()
+		}
+		if !writer.timedOut {
+			writer.flush()
+			return
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			writeError(c, errTimeout)
+		}
+	}
+}
+
+// timeoutWriter buffers a response until it is known to be in time.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	mu       sync.Mutex
+	header   http.Header
+	status   int
+	body     bytes.Buffer
+	timedOut bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if status > 0 && !w.timedOut {
+		w.status = status
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status != 0
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Flush() {}
+
+// flush writes the buffered response.
+func (w *timeoutWriter) flush() {
+	header := w.ResponseWriter.Header()
+	for name := range header {
+		if _, present := w.header[name]; !present {
+			delete(header, name)
+		}
+	}
+	for name, values := range w.header {
+		header[name] = values
+	}
+	if w.status == 0 {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.WriteHeaderNow()
+	w.ResponseWriter.Write(w.body.Bytes())
+}
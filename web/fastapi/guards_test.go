@@ -0,0 +1,158 @@
+package fastapi_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"web/fastapi"
+)
+
+type note struct {
+	Text string `json:"text"`
+}
+
+type delayInput struct {
+	Milliseconds int `query:"delay_ms" default:"0"`
+}
+
+func guardsRouter() *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Defaults(fastapi.MaxBodySize(32))
+	r.Handle(http.MethodGet, "/slow", func(c *gin.Context, in delayInput) (note, error) {
+		if in.Milliseconds > 0 {
+			// Handlers going on past the deadline do not delay the 504
+			// and what they write is dropped.
+			<-c.Request.Context().Done()
+			c.Header("X-Late", "true")
+			time.Sleep(time.Duration(in.Milliseconds) * time.Millisecond)
+		}
+		c.Header("X-Handled", "true")
+		return note{Text: "done"}, nil
+	}, fastapi.Timeout(50*time.Millisecond))
+	r.Handle(http.MethodGet, "/panics", func(c *gin.Context, in struct{}) (note, error) {
+		panic("boom")
+	})
+	r.Handle(http.MethodGet, "/panics-in-time", func(c *gin.Context, in struct{}) (note, error) {
+		panic("boom")
+	}, fastapi.Timeout(time.Second))
+	r.Handle(http.MethodGet, "/middleware-panics", func(c *gin.Context, in struct{}) (note, error) {
+		return note{}, nil
+	}, fastapi.WithMiddleware(func(c *gin.Context, next func() error) error {
+		panic("boom")
+	}))
+	r.Handle(http.MethodPost, "/notes", func(c *gin.Context, in note) (note, error) {
+		return in, nil
+	})
+	r.Handle(http.MethodPost, "/forms", func(c *gin.Context, in struct {
+		Text string `form:"text"`
+	}) (note, error) {
+		return note{Text: in.Text}, nil
+	})
+	r.Handle(http.MethodPost, "/large", func(c *gin.Context, in note) (note, error) {
+		return in, nil
+	}, fastapi.MaxBodySize(1024))
+	return r
+}
+
+func TestTimeout(t *testing.T) {
+	r := guardsRouter()
+
+	recorder := serve(r.GinHandler, http.MethodGet, "/slow", "", nil)
+	expectStatus(t, recorder, http.StatusOK)
+	if recorder.Header().Get("X-Handled") != "true" {
+		t.Error("the headers of a response in time are lost")
+	}
+
+	start := time.Now()
+	recorder = serve(r.GinHandler, http.MethodGet, "/slow?delay_ms=500", "", nil)
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("answered after %v", elapsed)
+	}
+	expectStatus(t, recorder, http.StatusGatewayTimeout)
+	if code := problem(t, recorder).Code; code != "timeout" {
+		t.Errorf("code %q, want timeout", code)
+	}
+	if recorder.Header().Get("X-Late") != "" || recorder.Header().Get("X-Handled") != "" {
+		t.Error("a header set after the deadline was sent")
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	r := guardsRouter()
+	for _, target := range []string{"/panics", "/panics-in-time", "/middleware-panics"} {
+		t.Run(target, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodGet, target, "", nil)
+			expectStatus(t, recorder, http.StatusInternalServerError)
+			if got := problem(t, recorder); got.Code != "internal_error" || strings.Contains(got.Detail, "boom") {
+				t.Errorf("problem %+v, want an internal error not giving the panic away", got)
+			}
+		})
+	}
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/slow", "", nil), http.StatusOK)
+}
+
+// serveChunked sends body without a Content-Length.
+func serveChunked(r *fastapi.Router, target, contentType, body string) *httptest.ResponseRecorder {
+	engine := gin.New()
+	engine.Any("/*path", r.GinHandler)
+	req := httptest.NewRequest(http.MethodPost, target, io.MultiReader(strings.NewReader(body)))
+	req.Header.Set("Content-Type", contentType)
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestMaxBodySize(t *testing.T) {
+	long := `{"text": "` + strings.Repeat("x", 40) + `"}`
+	tests := []struct {
+		name   string
+		serve  func(r *fastapi.Router) *httptest.ResponseRecorder
+		status int
+	}{
+		{"within the limit", func(r *fastapi.Router) *httptest.ResponseRecorder {
+			return serve(r.GinHandler, http.MethodPost, "/notes", `{"text": "short"}`, nil)
+		}, http.StatusOK},
+		{"declared too long", func(r *fastapi.Router) *httptest.ResponseRecorder {
+			return serve(r.GinHandler, http.MethodPost, "/notes", long, nil)
+		}, http.StatusRequestEntityTooLarge},
+		{"chunked too long", func(r *fastapi.Router) *httptest.ResponseRecorder {
+			return serveChunked(r, "/notes", "application/json", long)
+		}, http.StatusRequestEntityTooLarge},
+		{"form too long", func(r *fastapi.Router) *httptest.ResponseRecorder {
+			return serveChunked(r, "/forms", "application/x-www-form-urlencoded", "text="+strings.Repeat("x", 40))
+		}, http.StatusRequestEntityTooLarge},
+		{"route limit", func(r *fastapi.Router) *httptest.ResponseRecorder {
+			return serveChunked(r, "/large", "application/json", long)
+		}, http.StatusOK},
+	}
+	r := guardsRouter()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := test.serve(r)
+			expectStatus(t, recorder, test.status)
+			if test.status == http.StatusRequestEntityTooLarge {
+				if got := problem(t, recorder); got.Code != "body_too_large" || got.Detail != "request body exceeds 32 bytes" {
+					t.Errorf("problem %+v, want body_too_large", got)
+				}
+			}
+		})
+	}
+}
+
+func TestGuardsSpec(t *testing.T) {
+	paths := guardsRouter().EmitOpenAPIDefinition().Paths.Paths
+	if _, present := paths["/slow"].Get.Responses.StatusCodeResponses[http.StatusGatewayTimeout]; !present {
+		t.Error("the timeout is not documented")
+	}
+	if _, present := paths["/slow"].Get.Responses.StatusCodeResponses[http.StatusRequestEntityTooLarge]; present {
+		t.Error("413 is documented for a route without a body")
+	}
+	resp, present := paths["/notes"].Post.Responses.StatusCodeResponses[http.StatusRequestEntityTooLarge]
+	if !present || resp.Description != "Request body over 32 bytes" {
+		t.Errorf("413 described %q, want the limit", resp.Description)
+	}
+}
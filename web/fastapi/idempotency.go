@@ -0,0 +1,198 @@
+package fastapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const idempotencyHeader = "Idempotency-Key"
+
+// Idempotent makes retries of the route safe: the first response to a
+// request with an Idempotency-Key header is stored for ttl, 24 hours when
+// zero, and replayed to later requests with the same key from the same
+// principal. Those sent while the first is served get 409, and those with a
+// different path, query or body 422. Server errors are not stored, so that
+// they can be retried. Requests without the header are served as usual.
+func Idempotent(ttl time.Duration) RouteOption {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return func(rt *route) {
+		rt.idempotencyTTL = ttl
+	}
+}
+
+// IdempotencyRecord is a request with an Idempotency-Key and, once Done, its
+// response.
+type IdempotencyRecord struct {
+	// Fingerprint is the hash of the request URI and body.
+	Fingerprint string
+	Done        bool
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore keeps the records of idempotent requests.
+type IdempotencyStore interface {
+	// Claim stores record at key unless a record is there already, which
+	// is returned instead.
+	Claim(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error)
+	Save(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+// idempotency holds the store of a router's idempotent routes, shared by the
+// router and its groups.
+type idempotency struct {
+	store IdempotencyStore
+}
+
+// UseIdempotencyStore keeps the records of idempotent requests in store,
+// e.g. a RedisIdempotencyStore shared by every instance of a service.
+// Records are kept in memory by default.
+func (r *Router) UseIdempotencyStore(store IdempotencyStore) {
+	r.idempotency.store = store
+}
+
+var (
+	errIdempotencyInProgress = NewError(http.StatusConflict, "idempotency_conflict", "a request with this Idempotency-Key is in progress")
+	errIdempotencyKeyReused  = NewError(http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was used for a different request")
+)
+
+// serveIdempotent serves a request to an idempotent route with next, or
+// replays the response stored for its key.
+func (r *Router) serveIdempotent(c *gin.Context, rt *route, next func() error) error {
+	key := c.GetHeader(idempotencyHeader)
+	if key == "" {
+		return next()
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if tooLarge := bodyTooLarge(err); tooLarge != nil {
+		return tooLarge
+	}
+	if err != nil {
+		return NewError(http.StatusBadRequest, "invalid_request", "unreadable body")
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	subject := ""
+	if principal := PrincipalFrom(c); principal != nil {
+		subject = principal.Scheme + ":" + principal.Subject
+	}
+	key = hashHex(rt.method, rt.path, subject, key)
+	fingerprint := hashHex(c.Request.URL.RequestURI(), string(body))
+	ctx := c.Request.Context()
+	existing, err := r.idempotency.store.Claim(ctx, key, IdempotencyRecord{Fingerprint: fingerprint}, rt.idempotencyTTL)
+	if err != nil {
+		return fmt.Errorf("claiming idempotency key: %w", err)
+	}
+	switch {
+	case existing == nil:
+	case existing.Fingerprint != fingerprint:
+		return errIdempotencyKeyReused
+	case !existing.Done:
+		return errIdempotencyInProgress
+	default:
+		header := c.Writer.Header()
+		for name, values := range existing.Header {
+			header[name] = values
+		}
+		header.Set("Idempotent-Replayed", "true")
+		c.Writer.WriteHeader(existing.Status)
+		c.Writer.WriteHeaderNow()
+		c.Writer.Write(existing.Body)
+		return nil
+	}
+
+	saved := false
+	defer func() {
+		if !saved {
+			r.idempotency.store.Release(context.WithoutCancel(ctx), key)
+		}
+	}()
+	writer := &recordingWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+	c.Writer = writer
+	if err := next(); err != nil {
+		writeError(c, err)
+	}
+	c.Writer = writer.ResponseWriter
+	if writer.status >= http.StatusInternalServerError {
+		return nil
+	}
+	saved = true
+	return r.idempotency.store.Save(context.WithoutCancel(ctx), key, IdempotencyRecord{
+		Fingerprint: fingerprint,
+		Done:        true,
+		Status:      writer.status,
+		Header:      writer.Header().Clone(),
+		Body:        writer.body.Bytes(),
+	}, rt.idempotencyTTL)
+}
+
+func hashHex(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		// Lengths keep the parts apart.
+		fmt.Fprintf(hash, "%d:%s", len(part), part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// MemoryIdempotencyStore keeps idempotency records in memory, for a single
+// instance of a service.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]memoryIdempotencyRecord
+	swept   time.Time
+}
+
+type memoryIdempotencyRecord struct {
+	IdempotencyRecord
+	expires time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]memoryIdempotencyRecord), swept: time.Now()}
+}
+
+func (s *MemoryIdempotencyStore) Claim(_ context.Context, key string, record IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.swept) > time.Minute {
+		for key, stored := range s.records {
+			if now.After(stored.expires) {
+				delete(s.records, key)
+			}
+		}
+		s.swept = now
+	}
+	if stored, present := s.records[key]; present && now.Before(stored.expires) {
+		return &stored.IdempotencyRecord, nil
+	}
+	s.records[key] = memoryIdempotencyRecord{record, now.Add(ttl)}
+	return nil, nil
+}
+
+func (s *MemoryIdempotencyStore) Save(_ context.Context, key string, record IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = memoryIdempotencyRecord{record, time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
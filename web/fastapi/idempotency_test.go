@@ -0,0 +1,143 @@
+package fastapi_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+	"web/fastapi"
+)
+
+type cancelInput struct {
+	ID     int    `path:"id"`
+	Reason string `json:"reason"`
+}
+
+type cancelOutput struct {
+	ID     int    `json:"id"`
+	Reason string `json:"reason"`
+	Call   int32  `json:"call"`
+}
+
+func idempotentRouter(handle func(in cancelInput) error) (*fastapi.Router, *int32) {
+	r := fastapi.NewRouter()
+	calls := new(int32)
+	r.Handle(http.MethodPost, "/orders/{id}/cancel", func(c *gin.Context, in cancelInput) (cancelOutput, error) {
+		call := atomic.AddInt32(calls, 1)
+		if err := handle(in); err != nil {
+			return cancelOutput{}, err
+		}
+		return cancelOutput{ID: in.ID, Reason: in.Reason, Call: call}, nil
+	}, fastapi.Idempotent(time.Minute))
+	return r, calls
+}
+
+func withKey(key string) http.Header {
+	return http.Header{"Idempotency-Key": {key}}
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	r, calls := idempotentRouter(func(cancelInput) error { return nil })
+	first := serve(r.GinHandler, http.MethodPost, "/orders/1/cancel", `{"reason": "late"}`, withKey("k"))
+	expectStatus(t, first, http.StatusOK)
+	replay := serve(r.GinHandler, http.MethodPost, "/orders/1/cancel", `{"reason": "late"}`, withKey("k"))
+	expectStatus(t, replay, http.StatusOK)
+	if replay.Header().Get("Idempotent-Replayed") != "true" || replay.Body.String() != first.Body.String() {
+		t.Errorf("replayed %q with header %q, want %q", replay.Body.String(), replay.Header().Get("Idempotent-Replayed"), first.Body.String())
+	}
+	if *calls != 1 {
+		t.Errorf("handler called %d times, want once", *calls)
+	}
+
+	// Other keys and requests without one are served.
+	expectStatus(t, serve(r.GinHandler, http.MethodPost, "/orders/1/cancel", `{"reason": "late"}`, withKey("other")), http.StatusOK)
+	expectStatus(t, serve(r.GinHandler, http.MethodPost, "/orders/1/cancel", `{"reason": "late"}`, nil), http.StatusOK)
+	if *calls != 3 {
+		t.Errorf("handler called %d times, want 3", *calls)
+	}
+}
+
+func TestIdempotentKeyReused(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{"other body", "/orders/1/cancel", `{"reason": "early"}`},
+		{"other path", "/orders/2/cancel", `{"reason": "late"}`},
+		{"other query", "/orders/1/cancel?force=true", `{"reason": "late"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, calls := idempotentRouter(func(cancelInput) error { return nil })
+			expectStatus(t, serve(r.GinHandler, http.MethodPost, "/orders/1/cancel", `{"reason": "late"}`, withKey("k")), http.StatusOK)
+			recorder := serve(r.GinHandler, http.MethodPost, test.target, test.body, withKey("k"))
+			expectStatus(t, recorder, http.StatusUnprocessableEntity)
+			if code := problem(t, recorder).Code; code != "idempotency_key_reused" {
+				t.Errorf("code %q, want idempotency_key_reused", code)
+			}
+			if *calls != 1 {
+				t.Errorf("handler called %d times, want once", *calls)
+			}
+		})
+	}
+}
+
+func TestIdempotentInProgress(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	r, _ := idempotentRouter(func(cancelInput) error {
+		close(started)
+		<-release
+		return nil
+	})
+	done := make(chan int)
+	go func() {
+		done <- serve(r.GinHandler, http.MethodPost, "/orders/1/cancel", `{}`, withKey("k")).Code
+	}()
+	<-started
+	recorder := serve(r.GinHandler, http.MethodPost, "/orders/1/cancel", `{}`, withKey("k"))
+	close(release)
+	expectStatus(t, recorder, http.StatusConflict)
+	if code := problem(t, recorder).Code; code != "idempotency_conflict" {
+		t.Errorf("code %q, want idempotency_conflict", code)
+	}
+	if status := <-done; status != http.StatusOK {
+		t.Errorf("first request got %d, want 200", status)
+	}
+}
+
+func TestIdempotentServerErrorsRetried(t *testing.T) {
+	failures := 1
+	r, calls := idempotentRouter(func(cancelInput) error {
+		if failures > 0 {
+			failures--
+			return fastapi.NewError(http.StatusServiceUnavailable, "unavailable", "try again")
+		}
+		return nil
+	})
+	expectStatus(t, serve(r.GinHandler, http.MethodPost, "/orders/1/cancel", `{}`, withKey("k")), http.StatusServiceUnavailable)
+	expectStatus(t, serve(r.GinHandler, http.MethodPost, "/orders/1/cancel", `{}`, withKey("k")), http.StatusOK)
+	if *calls != 2 {
+		t.Errorf("handler called %d times, want twice", *calls)
+	}
+}
+
+func TestIdempotentPerPrincipal(t *testing.T) {
+	r, calls := idempotentRouter(func(cancelInput) error { return nil })
+	r.Secure(fastapi.Require(fastapi.APIKeyAuth("key", "header", "X-API-Key", func(c *gin.Context, key string) (*fastapi.Principal, error) {
+		return &fastapi.Principal{Subject: key}, nil
+	})))
+	for _, key := range []string{"alice", "bob"} {
+		header := withKey("k")
+		header.Set("X-API-Key", key)
+		recorder := serve(r.GinHandler, http.MethodPost, "/orders/1/cancel", `{}`, header)
+		expectStatus(t, recorder, http.StatusOK)
+		if recorder.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("replayed to %s the response of another principal", key)
+		}
+	}
+	if *calls != 2 {
+		t.Errorf("handler called %d times, want twice", *calls)
+	}
+}
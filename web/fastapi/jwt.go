@@ -0,0 +1,296 @@
+package fastapi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	openapi "github.com/go-openapi/spec"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// JWTConfig verifies bearer tokens against a shared HMAC secret and/or the
+// public keys of a local JSON Web Key Set. Issuer and Audience are checked
+// when set.
+type JWTConfig struct {
+	HMACSecret []byte
+	JWKS       []byte
+	Issuer     string
+	Audience   string
+	Leeway     time.Duration
+}
+
+type jwtVerifier struct {
+	parser  *jwt.Parser
+	secret  []byte
+	keys    map[string]interface{}
+	methods []string
+}
+
+func newJWTVerifier(config JWTConfig) *jwtVerifier {
+	verifier := &jwtVerifier{secret: config.HMACSecret, keys: make(map[string]interface{})}
+	if len(config.HMACSecret) > 0 {
+		verifier.methods = append(verifier.methods, "HS256", "HS384", "HS512")
+	}
+	if len(config.JWKS) > 0 {
+		keys, err := parseJWKS(config.JWKS)
+		if err != nil {
+			panic("Invalid JWKS: " + err.Error())
+		}
+		verifier.keys = keys
+		verifier.methods = append(verifier.methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512")
+	}
+	if len(verifier.methods) == 0 {
+		panic("JWT verification needs an HMAC secret or a JWKS")
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(verifier.methods), jwt.WithLeeway(config.Leeway), jwt.WithExpirationRequired()}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	verifier.parser = jwt.NewParser(options...)
+	return verifier
+}
+
+func (v *jwtVerifier) key(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		return v.secret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	if key, present := v.keys[kid]; present {
+		return key, nil
+	}
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+func (v *jwtVerifier) verify(raw string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(raw, claims, v.key); err != nil {
+		return nil, ErrInvalidCredential.WithDetails(err.Error())
+	}
+	subject, _ := claims.GetSubject()
+	return &Principal{Subject: subject, Scopes: tokenScopes(claims), Claims: claims}, nil
+}
+
+// tokenScopes reads the space separated "scope" claim, or the "scp" claim
+// some providers send as a list.
+func tokenScopes(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	var scopes []string
+	switch scp := claims["scp"].(type) {
+	case string:
+		scopes = strings.Fields(scp)
+	case []interface{}:
+		for _, scope := range scp {
+			if s, ok := scope.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func parseJWKS(document []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(document, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var key interface{}
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = rsaKey(jwk)
+		case "EC":
+			key, err = ecKey(jwk)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no RSA or EC signing keys")
+	}
+	return keys, nil
+}
+
+func rsaKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("exponent too large")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func ecKey(jwk jsonWebKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil {
+		return nil, err
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, fmt.Errorf("point is not on curve %s", jwk.Crv)
+	}
+	return key, nil
+}
+
+type bearerJWTScheme struct {
+	name     string
+	verifier *jwtVerifier
+}
+
+// BearerJWT authenticates "Authorization: Bearer" tokens signed with the
+// configured keys. The principal's subject and scopes come from the "sub"
+// and "scope" claims.
+func BearerJWT(name string, config JWTConfig) SecurityScheme {
+	return &bearerJWTScheme{name: name, verifier: newJWTVerifier(config)}
+}
+
+func (s *bearerJWTScheme) Name() string {
+	return s.name
+}
+
+func (s *bearerJWTScheme) Authenticate(c *gin.Context) (*Principal, error) {
+	token, err := bearerToken(c)
+	if err != nil {
+		return nil, err
+	}
+	return s.verifier.verify(token)
+}
+
+func (s *bearerJWTScheme) Challenge() string {
+	return "Bearer"
+}
+
+func (s *bearerJWTScheme) SwaggerDefinition() *openapi.SecurityScheme {
+	scheme := openapi.APIKeyAuth("Authorization", "header")
+	scheme.Description = "JWT sent as \"Bearer <token>\""
+	return scheme
+}
+
+func (s *bearerJWTScheme) OpenAPIDefinition() OpenAPISecurityScheme {
+	return OpenAPISecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+}
+
+// OAuth2Flow describes where clients obtain tokens. Type is one of
+// "implicit", "password", "clientCredentials" or "authorizationCode".
+type OAuth2Flow struct {
+	Type             string
+	AuthorizationURL string
+	TokenURL         string
+	Scopes           map[string]string
+}
+
+type oauth2Scheme struct {
+	bearerJWTScheme
+	flow OAuth2Flow
+}
+
+// OAuth2 documents an OAuth2 flow and authenticates the JWT access tokens
+// it issues like BearerJWT.
+func OAuth2(name string, flow OAuth2Flow, config JWTConfig) SecurityScheme {
+	switch flow.Type {
+	case "implicit", "password", "clientCredentials", "authorizationCode":
+	default:
+		panic("Unsupported OAuth2 flow " + flow.Type)
+	}
+	return &oauth2Scheme{bearerJWTScheme{name: name, verifier: newJWTVerifier(config)}, flow}
+}
+
+func (s *oauth2Scheme) SwaggerDefinition() *openapi.SecurityScheme {
+	var scheme *openapi.SecurityScheme
+	switch s.flow.Type {
+	case "implicit":
+		scheme = openapi.OAuth2Implicit(s.flow.AuthorizationURL)
+	case "password":
+		scheme = openapi.OAuth2Password(s.flow.TokenURL)
+	case "clientCredentials":
+		scheme = openapi.OAuth2Application(s.flow.TokenURL)
+	default:
+		scheme = openapi.OAuth2AccessToken(s.flow.AuthorizationURL, s.flow.TokenURL)
+	}
+	for scope, description := range s.flow.Scopes {
+		scheme.AddScope(scope, description)
+	}
+	return scheme
+}
+
+func (s *oauth2Scheme) OpenAPIDefinition() OpenAPISecurityScheme {
+	scopes := s.flow.Scopes
+	if scopes == nil {
+		scopes = map[string]string{}
+	}
+	flow := &OpenAPIOAuthFlow{AuthorizationURL: s.flow.AuthorizationURL, TokenURL: s.flow.TokenURL, Scopes: scopes}
+	flows := &OpenAPIOAuthFlows{}
+	switch s.flow.Type {
+	case "implicit":
+		flows.Implicit = flow
+	case "password":
+		flows.Password = flow
+	case "clientCredentials":
+		flows.ClientCredentials = flow
+	default:
+		flows.AuthorizationCode = flow
+	}
+	return OpenAPISecurityScheme{Type: "oauth2", Flows: flows}
+}
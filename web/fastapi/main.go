@@ -3,23 +3,131 @@ package fastapi
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	openapi "github.com/go-openapi/spec"
-	"log"
 	"net/http"
 	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
+var supportedMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
 type Router struct {
-	routesMap map[string]interface{}
+	// path -> method -> route, shared by the router and its groups
+	routesMap map[string]map[string]*route
+	tree      *node
+	// generation changes whenever a route is added, invalidating cached specs.
+	generation      *atomic.Uint64
+	providers       map[reflect.Type]provider
+	tagDescriptions map[string]string
+	contract        *contractCache
+	versions        *versioning
+	schemas         map[reflect.Type]openapi.Schema
+	rateLimits      *rateLimiting
+	idempotency     *idempotency
+	caching         *responseCaching
+
+	parent      *Router
+	prefix      string
+	version     string
+	tags        []string
+	defaults    []RouteOption
+	middlewares []Middleware
+	security    []SecurityRequirement
 }
 
 func NewRouter() *Router {
+	r := &Router{
+		routesMap:       make(map[string]map[string]*route),
+		tree:            newNode(),
+		generation:      new(atomic.Uint64),
+		providers:       make(map[reflect.Type]provider),
+		tagDescriptions: make(map[string]string),
+		contract:        &contractCache{},
+		schemas:         make(map[reflect.Type]openapi.Schema),
+		rateLimits:      &rateLimiting{store: NewMemoryRateLimitStore(), clientIP: (*gin.Context).RemoteIP},
+		idempotency:     &idempotency{store: NewMemoryIdempotencyStore()},
+		caching:         &responseCaching{cache: NewMemoryResponseCache(1024)},
+		versions: &versioning{
+			names: make(map[string]bool),
+			usage: make(map[*route]*DeprecatedUsage),
+		},
+	}
+	Provide(r, resolvePrincipal)
+	return r
+}
+
+// Group returns a router registering its routes under prefix with the given
+// tags. Groups share routes, providers and docs with their parent and add
+// their own middleware, security and default route options to the parent's.
+func (r *Router) Group(prefix string, tags ...string) *Router {
 	return &Router{
-		routesMap: make(map[string]interface{}),
+		routesMap:       r.routesMap,
+		tree:            r.tree,
+		generation:      r.generation,
+		providers:       r.providers,
+		tagDescriptions: r.tagDescriptions,
+		contract:        r.contract,
+		versions:        r.versions,
+		schemas:         r.schemas,
+		rateLimits:      r.rateLimits,
+		idempotency:     r.idempotency,
+		caching:         r.caching,
+		parent:          r,
+		prefix:          joinPath(r.prefix, prefix),
+		tags:            tags,
+	}
+}
+
+// Defaults adds options applied to every route later registered on the
+// router or its groups, before the route's own options.
+func (r *Router) Defaults(opts ...RouteOption) {
+	r.defaults = append(r.defaults, opts...)
+}
+
+// DescribeTag sets the description listed for a tag in the spec.
+func (r *Router) DescribeTag(name, description string) {
+	r.tagDescriptions[name] = description
+	r.generation.Add(1)
+}
+
+// lineage returns the router's ancestors and itself, outermost first.
+func (r *Router) lineage() []*Router {
+	var routers []*Router
+	for current := r; current != nil; current = current.parent {
+		routers = append([]*Router{current}, routers...)
+	}
+	return routers
+}
+
+// root returns the router the router's groups descend from.
+func (r *Router) root() *Router {
+	for r.parent != nil {
+		r = r.parent
 	}
+	return r
+}
+
+func joinPath(prefix, path string) string {
+	return "/" + strings.Join(append(splitPath(prefix), splitPath(path)...), "/")
+}
+
+func (r *Router) AddCall(path string, handler interface{}, opts ...RouteOption) {
+	r.Handle(http.MethodPost, path, handler, opts...)
 }
 
-func (r *Router) AddCall(path string, handler interface{}) {
+func (r *Router) Handle(method, path string, handler interface{}, opts ...RouteOption) {
 	handlerType := reflect.TypeOf(handler)
 
 	if handlerType.NumIn() != 2 {
@@ -33,7 +141,6 @@ func (r *Router) AddCall(path string, handler interface{}) {
 	if !handlerType.In(0).ConvertibleTo(ginCtxType) {
 		panic("First argument should be *gin.Context!")
 	}
-	// fmt.Println(handlerType.In(1).Kind() == reflect.Struct)
 	if handlerType.In(1).Kind() != reflect.Struct {
 		panic("Second argument must be a struct")
 	}
@@ -46,42 +153,237 @@ func (r *Router) AddCall(path string, handler interface{}) {
 		panic("First return value be a struct")
 	}
 
-	r.routesMap[path] = handler
+	toCall := reflect.ValueOf(handler)
+	invoke := func(c *gin.Context, inputPtr interface{}) (interface{}, error) {
+		outputVal := toCall.Call(
+			[]reflect.Value{
+				reflect.ValueOf(c),
+				reflect.ValueOf(inputPtr).Elem(),
+			},
+		)
+		if !outputVal[1].IsNil() {
+			return nil, outputVal[1].Interface().(error)
+		}
+		return outputVal[0].Interface(), nil
+	}
+
+	r.addRoute(&route{
+		method:     method,
+		path:       path,
+		inputType:  handlerType.In(1),
+		outputType: handlerType.Out(0),
+		invoke:     invoke,
+	}, opts)
+}
+
+func (r *Router) addRoute(rt *route, opts []RouteOption) {
+	rt.method = strings.ToUpper(rt.method)
+	if !supportedMethods[rt.method] {
+		panic("Unsupported HTTP method " + rt.method)
+	}
+	rt.path = joinPath(r.prefix, rt.path)
+	checkPathParams(rt.path, rt.inputType)
+	checkFormFields(rt.method, rt.inputType)
+	r.checkDependencies(rt.inputType)
+	checked := make(map[reflect.Type]bool)
+	checkConstraints(rt.inputType, checked)
+	if rt.inbound != nil {
+		checkConstraints(rt.inbound, checked)
+	}
+
+	found := r.tree.insert(rt.path)
+	if _, present := found.methods[rt.method]; present {
+		panic("Duplicate route " + rt.method + " " + rt.path)
+	}
+	rt.path = found.pattern
+	rt.group = r
+	for _, group := range r.lineage() {
+		rt.tags = append(rt.tags, group.tags...)
+		for _, opt := range group.defaults {
+			opt(rt)
+		}
+	}
+	for _, opt := range opts {
+		opt(rt)
+	}
+	checkProduces(rt)
+	if rt.operationID != "" {
+		for _, methods := range r.routesMap {
+			for _, other := range methods {
+				if other.operationID == rt.operationID {
+					panic("Duplicate operationId " + rt.operationID)
+				}
+			}
+		}
+	}
+	found.methods[rt.method] = rt
+	r.routesMap[found.pattern] = found.methods
+	r.generation.Add(1)
+}
+
+func checkPathParams(path string, inputType reflect.Type) {
+	declared := make(map[string]bool)
+	for _, name := range pathParamNames(path) {
+		declared[name] = true
+	}
+	eachTaggedField(reflect.New(inputType).Elem(), "path", func(name string, _ reflect.Value, _ reflect.StructField) error {
+		if !declared[name] {
+			panic("Path parameter {" + name + "} is not declared in " + path)
+		}
+		return nil
+	})
 }
 
 func (r *Router) GinHandler(c *gin.Context) {
 	path := c.Param("path")
-	log.Print(path)
-	handlerFuncPtr, present := r.routesMap[path]
+	path, err := r.versionedPath(c, path)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	found, params := r.tree.lookup(path)
+	var methods map[string]*route
+	if found != nil {
+		methods = r.served(found.methods)
+	}
+	if len(methods) == 0 {
+		writeError(c, NewError(http.StatusNotFound, "not_found", "handler not found"))
+		return
+	}
+	rt, present := methods[c.Request.Method]
 	if !present {
-		c.JSON(http.StatusNotFound, gin.H{"error": "handler not found"})
+		c.Header("Allow", allowedMethods(methods))
+		writeError(c, NewError(http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed"))
 		return
 	}
 
-	handlerType := reflect.TypeOf(handlerFuncPtr)
-	inputType := handlerType.In(1)
+	c.Set(routerContextKey, r)
+	r.announceDeprecation(c, rt)
+	if err := limitBody(c, rt); err != nil {
+		writeError(c, err)
+		return
+	}
+	var chain []Middleware
+	for _, group := range rt.group.lineage() {
+		chain = append(chain, group.middlewares...)
+	}
+	chain = append(chain, rt.middlewares...)
+	serve := timedOut(rt, recovered(rt, func(c *gin.Context) {
+		err := authenticate(c, securityFor(rt))
+		if err == nil {
+			err = runChain(c, chain, func() error {
+				return r.serve(c, rt, params)
+			})
+		}
+		if err != nil {
+			writeError(c, err)
+		}
+	}))
+	if mode := ContractMode(r.contract.mode.Load()); mode != ContractOff {
+		unchecked := serve
+		serve = recovered(rt, func(c *gin.Context) {
+			r.serveChecked(c, mode, found.pattern, rt, params, func() { unchecked(c) })
+		})
+	}
+	serve(c)
+}
+
+// serve applies the route's rate limits and idempotency before handling the
+// request.
+func (r *Router) serve(c *gin.Context, rt *route, params map[string]string) error {
+	if err := r.limitRate(c, rt); err != nil {
+		return err
+	}
+	if rt.idempotencyTTL > 0 {
+		return r.serveIdempotent(c, rt, func() error {
+			return handle(c, rt, params)
+		})
+	}
+	return handle(c, rt, params)
+}
+
+// handle runs the route's dependencies, binds and validates its input, calls
+// the handler and writes the response.
+func handle(c *gin.Context, rt *route, params map[string]string) error {
+	var mediaType string
+	if rt.negotiated() {
+		var err error
+		if mediaType, err = negotiate(c, rt); err != nil {
+			return err
+		}
+	}
+	for _, dependency := range rt.dependencies {
+		if err := dependency(c); err != nil {
+			return err
+		}
+	}
+	inject, err := resolveDependencies(c, rt.inputType)
+	if err != nil {
+		return err
+	}
+
+	inputType := rt.inputType
 	inputVal := reflect.New(inputType).Interface()
-	err := c.BindJSON(inputVal)
+	if hasRequestBody(c.Request.Method) && hasFormFields(inputType) {
+		if err := parseForm(c); err != nil {
+			return err
+		}
+	}
+	var body []byte
+	if hasRequestBody(c.Request.Method) && hasBodyFields(inputType) {
+		err := c.ShouldBindBodyWith(inputVal, binding.JSON)
+		if verr := bodyError(err); verr != nil {
+			return verr
+		}
+		if tooLarge := bodyTooLarge(err); tooLarge != nil {
+			return tooLarge
+		}
+		if err != nil {
+			return NewError(http.StatusBadRequest, "invalid_request", "invalid request")
+		}
+		clearNonBodyFields(reflect.ValueOf(inputVal).Elem())
+		body = c.MustGet(gin.BodyBytesKey).([]byte)
+	}
+	inject(reflect.ValueOf(inputVal).Elem())
+	err = bindParams(c, reflect.ValueOf(inputVal).Elem(), params)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
-		return
+		return err
+	}
+	if verr := validateInput(reflect.ValueOf(inputVal).Elem(), body); verr != nil {
+		return verr
 	}
 
-	toCall := reflect.ValueOf(handlerFuncPtr)
-	outputVal := toCall.Call(
-		[]reflect.Value{
-			reflect.ValueOf(c),
-			reflect.ValueOf(inputVal).Elem(),
-		},
-	)
+	if rt.cached() {
+		return serveCached(c, rt, mediaType, inputVal, func() (interface{}, error) {
+			return rt.invoke(c, inputVal)
+		})
+	}
+	output, err := rt.invoke(c, inputVal)
+	if err != nil {
+		return err
+	}
+	return writeResponse(c, rt, mediaType, output)
+}
 
-	returnedErr := outputVal[1].Interface()
-	if returnedErr != nil || !outputVal[1].IsNil() {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": returnedErr})
-		return
+func hasRequestBody(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
 	}
+	return false
+}
 
-	c.JSON(http.StatusOK, gin.H{"response": outputVal[0].Interface()})
+func allowedMethods(methods map[string]*route) string {
+	return strings.Join(sortedMethods(methods), ", ")
+}
+
+func sortedMethods(methods map[string]*route) []string {
+	sorted := make([]string, 0, len(methods))
+	for method := range methods {
+		sorted = append(sorted, method)
+	}
+	sort.Strings(sorted)
+	return sorted
 }
 
 func (r *Router) EmitOpenAPIDefinition() openapi.Swagger {
@@ -90,112 +392,455 @@ func (r *Router) EmitOpenAPIDefinition() openapi.Swagger {
 	sw.Info = &openapi.Info{}
 	sw.Info.Title = "API generated with go-fastapi"
 	sw.Info.Version = "1.0"
+	if r.version != "" {
+		sw.Info.Version = r.version
+	}
 	sw.Paths = &openapi.Paths{
 		Paths: make(map[string]openapi.PathItem),
 	}
-	sw.Definitions = make(map[string]openapi.Schema)
 
-	definitionTypes := make(map[string]reflect.Type)
-	for path, handlerFuncPtr := range r.routesMap {
-		handlerType := reflect.TypeOf(handlerFuncPtr)
-		inputType := handlerType.In(1)
-		definitionTypes[inputType.Name()] = inputType
-		for i := 0; i < inputType.NumField(); i++ {
-			field := inputType.Field(i)
-			if field.Type.Kind() == reflect.Struct {
-				definitionTypes[field.Type.Name()] = field.Type
+	for _, scheme := range r.securitySchemes() {
+		if definition := scheme.SwaggerDefinition(); definition != nil {
+			if sw.SecurityDefinitions == nil {
+				sw.SecurityDefinitions = make(openapi.SecurityDefinitions)
 			}
+			sw.SecurityDefinitions[scheme.Name()] = definition
 		}
+	}
 
-		outputType := handlerType.Out(0)
-		definitionTypes[outputType.Name()] = outputType
-		for i := 0; i < outputType.NumField(); i++ {
-			field := outputType.Field(i)
-			if field.Type.Kind() == reflect.Struct {
-				definitionTypes[field.Type.Name()] = field.Type
+	operations, definitions := r.describe()
+	for _, operation := range operations {
+		op := *operation.op
+		op.Parameters = nil
+		for _, param := range operation.op.Parameters {
+			// Swagger 2.0 has no way to describe cookie parameters.
+			if param.In != "cookie" {
+				op.Parameters = append(op.Parameters, param)
+			}
+		}
+		op.Security = nil
+		for _, requirement := range operation.op.Security {
+			for name := range requirement {
+				if _, present := sw.SecurityDefinitions[name]; present {
+					op.Security = append(op.Security, requirement)
+				}
 			}
 		}
+		pi := sw.Paths.Paths[operation.path]
+		setOperation(&pi, operation.method, &op)
+		sw.Paths.Paths[operation.path] = pi
+	}
+	sw.Definitions = definitions
+	sw.Tags = r.specTags()
 
-		param := openapi.Parameter{}
-		param.Name = "body"
-		param.In = "body"
-		param.Required = true
-		param.Schema = openapi.RefSchema(
-			fmt.Sprintf("#/definitions/%s", inputType.Name()),
-		)
+	return sw
+}
 
-		op := &openapi.Operation{}
-		op.Parameters = []openapi.Parameter{param}
-		op.Responses = &openapi.Responses{}
-		op.Responses.StatusCodeResponses = make(map[int]openapi.Response)
-		ref := openapi.ResponseRef(
-			fmt.Sprintf("#/definitions/%s", outputType.Name()),
-		)
-		op.Responses.StatusCodeResponses[http.StatusOK] = *ref
+// operationSpec is a route described in Swagger 2.0 terms, shared by the
+// emitters of every supported document version.
+type operationSpec struct {
+	method string
+	path   string
+	op     *openapi.Operation
+	// problems holds the statuses answered with a problem document.
+	problems map[int]bool
+}
 
-		pi := openapi.PathItem{}
-		pi.Post = op
-		sw.Paths.Paths[path] = pi
+func (r *Router) describe() ([]operationSpec, openapi.Definitions) {
+	var operations []operationSpec
+	gen := newSchemaGenerator(r.schemas)
+	paths := make([]string, 0, len(r.routesMap))
+	for path := range r.routesMap {
+		paths = append(paths, path)
 	}
-
-	for definitionName, definitionType := range definitionTypes {
-		props := make(map[string]openapi.Schema)
-		for i := 0; i < definitionType.NumField(); i++ {
-			field := definitionType.Field(i)
-			fieldName := field.Tag.Get("json")
-			if fieldName == "-" {
+	sort.Strings(paths)
+	operationIDs := make(map[string]bool)
+	for _, methods := range r.routesMap {
+		for _, rt := range methods {
+			operationIDs[rt.operationID] = rt.operationID != ""
+		}
+	}
+	for _, path := range paths {
+		methods := r.routesMap[path]
+		for _, method := range sortedMethods(methods) {
+			rt := methods[method]
+			if !r.documents(rt) {
 				continue
 			}
-			if fieldName == "" {
-				fieldName = field.Name
+			inputType := rt.inputType
+
+			op := &openapi.Operation{}
+			op.ID = rt.operationID
+			if op.ID == "" {
+				op.ID = uniqueName(operationName(method, path), operationIDs)
+			}
+			op.Summary = rt.summary
+			op.Description = rt.description
+			op.Tags = rt.tags
+			op.Deprecated = rt.deprecated
+			if !rt.sunset.IsZero() {
+				op.AddExtension("x-sunset", rt.sunset.UTC().Format(time.RFC3339))
+			}
+			if len(rt.rateLimits) > 0 {
+				op.AddExtension("x-ratelimit", rateLimitExtensions(rt.rateLimits))
 			}
+			op.Parameters = parameters(path, inputType, gen)
+			if rt.idempotencyTTL > 0 && !hasParameter(op.Parameters, "header", idempotencyHeader) {
+				param := openapi.HeaderParam(idempotencyHeader).Typed("string", "")
+				param.Description = "Makes retries safe: responses are replayed for requests with the same key for " + rt.idempotencyTTL.String()
+				op.Parameters = append(op.Parameters, *param)
+			}
+			if hasRequestBody(method) && hasBodyFields(inputType) {
+				schema := gen.schemaFor(inputType)
+				schema.Example = rt.requestExample
+				param := openapi.Parameter{}
+				param.Name = "body"
+				param.In = "body"
+				param.Required = true
+				param.Schema = &schema
+				op.Parameters = append(op.Parameters, param)
+			}
+			if hasFormFields(inputType) {
+				op.Consumes = formMediaTypes(inputType)
+			}
+			op.Responses = &openapi.Responses{}
+			op.Responses.StatusCodeResponses = make(map[int]openapi.Response)
+			addSuccessResponses(op, rt, gen)
+			if rt.etag && (method == http.MethodGet || method == http.MethodHead) {
+				op.Responses.StatusCodeResponses[http.StatusNotModified] = *openapi.NewResponse().WithDescription("Not modified since the ETag listed in If-None-Match")
+				if !hasParameter(op.Parameters, "header", "If-None-Match") {
+					param := openapi.HeaderParam("If-None-Match").Typed("string", "")
+					param.Description = "ETags of the cached responses"
+					op.Parameters = append(op.Parameters, *param)
+				}
+			}
+			addWebSocketSpec(op, rt, gen)
+			op.Security = securityRequirements(securityFor(rt))
+			problems := addErrorResponses(op, rt, securityFor(rt), gen)
 
-			schema := swaggerTypeFromGoType(field.Type)
-			if schema == nil {
-				continue
+			operations = append(operations, operationSpec{method: method, path: path, op: op, problems: problems})
+		}
+	}
+
+	return operations, gen.definitions
+}
+
+// operationName derives an operationId from a route, e.g. getUsersById for
+// GET /users/{id}.
+func operationName(method, path string) string {
+	name := strings.ToLower(method)
+	for _, segment := range splitPath(path) {
+		if param, ok := paramName(segment); ok {
+			name += "By" + camelCase(param)
+		} else {
+			name += camelCase(segment)
+		}
+	}
+	return name
+}
+
+func camelCase(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	})
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, "")
+}
+
+// uniqueName returns name, suffixed with a number if taken, and marks it
+// taken.
+func uniqueName(name string, taken map[string]bool) string {
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// specTags lists the tags used by any route, in order of first use, with
+// their descriptions.
+func (r *Router) specTags() []openapi.Tag {
+	var tags []openapi.Tag
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			tags = append(tags, openapi.NewTag(name, r.tagDescriptions[name], nil))
+		}
+	}
+	paths := make([]string, 0, len(r.routesMap))
+	for path := range r.routesMap {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, method := range sortedMethods(r.routesMap[path]) {
+			if rt := r.routesMap[path][method]; r.documents(rt) {
+				for _, name := range rt.tags {
+					add(name)
+				}
+			}
+		}
+	}
+	described := make([]string, 0, len(r.tagDescriptions))
+	for name := range r.tagDescriptions {
+		described = append(described, name)
+	}
+	sort.Strings(described)
+	for _, name := range described {
+		add(name)
+	}
+	return tags
+}
+
+var problemType = reflect.TypeOf(Problem{})
+
+// addErrorResponses documents the problem responses of an operation: the
+// errors declared on the route, 422 whenever the route binds any input, 400
+// when it reads a body, 406 when it negotiates its media type, 401/403 when
+// it is secured, 429 when it is rate limited and 413 and 504 when its body
+// size or time are bounded. It returns the statuses it documented.
+func addErrorResponses(op *openapi.Operation, rt *route, security []SecurityRequirement, gen *schemaGenerator) map[int]bool {
+	descriptions := make(map[int][]string)
+	for _, declared := range rt.errors {
+		status := declared.HTTPStatus()
+		description := declared.Error()
+		if typed, ok := declared.(*Error); ok {
+			description = fmt.Sprintf("`%s`: %s", typed.Code, typed.Message)
+		}
+		descriptions[status] = append(descriptions[status], description)
+	}
+	if len(op.Parameters) > 0 {
+		if _, present := descriptions[http.StatusUnprocessableEntity]; !present {
+			descriptions[http.StatusUnprocessableEntity] = []string{"Validation failed"}
+		}
+	}
+	if _, present := descriptions[http.StatusBadRequest]; !present && hasRequestBody(rt.method) && (hasBodyFields(rt.inputType) || hasFormFields(rt.inputType)) {
+		descriptions[http.StatusBadRequest] = []string{"Malformed request body"}
+	}
+	if _, present := descriptions[http.StatusNotAcceptable]; !present && rt.negotiated() {
+		descriptions[http.StatusNotAcceptable] = []string{"None of the accepted media types is offered"}
+	}
+	if len(security) > 0 {
+		if _, present := descriptions[http.StatusUnauthorized]; !present {
+			descriptions[http.StatusUnauthorized] = []string{"Authentication required"}
+		}
+		for _, requirement := range security {
+			if _, present := descriptions[http.StatusForbidden]; !present && len(requirement.Scopes) > 0 {
+				descriptions[http.StatusForbidden] = []string{"Insufficient scope"}
+			}
+		}
+	}
+	if _, present := descriptions[http.StatusTooManyRequests]; !present && len(rt.rateLimits) > 0 {
+		descriptions[http.StatusTooManyRequests] = []string{"Rate limit exceeded, retry after the Retry-After header's seconds"}
+	}
+	if rt.idempotencyTTL > 0 {
+		for _, idempotencyErr := range []*Error{errIdempotencyInProgress, errIdempotencyKeyReused} {
+			descriptions[idempotencyErr.Status] = append(descriptions[idempotencyErr.Status], fmt.Sprintf("`%s`: %s", idempotencyErr.Code, idempotencyErr.Message))
+		}
+	}
+	if _, present := descriptions[http.StatusRequestEntityTooLarge]; !present && rt.maxBodySize > 0 && hasRequestBody(rt.method) {
+		descriptions[http.StatusRequestEntityTooLarge] = []string{fmt.Sprintf("Request body over %d bytes", rt.maxBodySize)}
+	}
+	if _, present := descriptions[http.StatusGatewayTimeout]; !present && rt.timeout > 0 && rt.events == nil && rt.inbound == nil {
+		descriptions[http.StatusGatewayTimeout] = []string{"Request timed out after " + rt.timeout.String()}
+	}
+	if len(descriptions) == 0 {
+		return nil
+	}
+
+	problems := make(map[int]bool)
+	problemSchema := gen.schemaFor(problemType)
+	for status, lines := range descriptions {
+		problems[status] = true
+		resp := openapi.NewResponse().
+			WithDescription(strings.Join(lines, "\n\n")).
+			WithSchema(&problemSchema)
+		op.Responses.StatusCodeResponses[status] = *resp
+	}
+	op.Produces = append(op.Produces, problemContentType)
+	return problems
+}
+
+// isNonBodyField reports whether a field is filled from somewhere other
+// than the request body: a parameter or an injected dependency.
+func isNonBodyField(field reflect.StructField) bool {
+	for _, in := range parameterLocations {
+		if _, present := field.Tag.Lookup(in); present {
+			return true
+		}
+	}
+	_, present := field.Tag.Lookup(dependsTag)
+	return present
+}
+
+// clearNonBodyFields zeroes the parameters and dependencies a body set, as
+// they only come from their own source: absent parameters would otherwise
+// keep the body's values.
+func clearNonBodyFields(structVal reflect.Value) {
+	if structVal.Kind() != reflect.Struct {
+		return
+	}
+	structType := structVal.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field, value := structType.Field(i), structVal.Field(i)
+		switch {
+		case isNonBodyField(field):
+			if value.CanSet() {
+				value.Set(reflect.Zero(field.Type))
 			}
-			props[fieldName] = *schema
+		case field.Anonymous && field.Type.Kind() == reflect.Struct:
+			clearNonBodyFields(value)
+		case field.Anonymous && field.Type.Kind() == reflect.Ptr && !value.IsNil():
+			clearNonBodyFields(value.Elem())
 		}
+	}
+}
 
-		var definition openapi.Schema
-		definition.Type = []string{"object"}
-		definition.Properties = props
-		sw.Definitions[definitionName] = definition
+func hasBodyFields(inputType reflect.Type) bool {
+	if inputType.Kind() != reflect.Struct {
+		return true
 	}
+	for i := 0; i < inputType.NumField(); i++ {
+		field := inputType.Field(i)
+		if !field.IsExported() || isNonBodyField(field) || field.Tag.Get("json") == "-" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !hasBodyFields(field.Type) {
+			continue
+		}
+		return true
+	}
+	return false
+}
 
-	return sw
+func parameters(path string, inputType reflect.Type, gen *schemaGenerator) []openapi.Parameter {
+	inputVal := reflect.New(inputType).Elem()
+	pathFields := make(map[string]reflect.StructField)
+	eachTaggedField(inputVal, "path", func(name string, _ reflect.Value, structField reflect.StructField) error {
+		pathFields[name] = structField
+		return nil
+	})
+
+	var params []openapi.Parameter
+	for _, name := range pathParamNames(path) {
+		param := openapi.PathParam(name)
+		param.Typed("string", "")
+		if pathField, present := pathFields[name]; present {
+			typeParam(&param.SimpleSchema, &param.CommonValidations, gen.simpleSchema(pathField.Type))
+			applyConstraints(&param.CommonValidations, &param.Format, pathField.Type, parseConstraints(pathField.Tag))
+		}
+		params = append(params, *param)
+	}
+
+	for _, in := range parameterLocations[1:] {
+		eachTaggedField(inputVal, in, func(name string, field reflect.Value, structField reflect.StructField) error {
+			param := &openapi.Parameter{ParamProps: openapi.ParamProps{Name: name, In: in}}
+			if in == "form" {
+				param.In = "formData"
+			}
+			if isFileField(field.Type()) {
+				// Swagger 2.0 has no arrays of files, x-multiple keeps the
+				// information for OpenAPI 3.1.
+				param.Typed("file", "")
+				if field.Type() == fileHeadersType {
+					param.AddExtension("x-multiple", true)
+				}
+			} else if isListParam(field.Type()) {
+				param.Typed("array", "")
+				param.Items = openapi.NewItems()
+				items := gen.simpleSchema(field.Type().Elem())
+				typeParam(&param.Items.SimpleSchema, &param.Items.CommonValidations, items)
+				param.Description = items.Description
+				param.CollectionFormat = "csv"
+				if in == "query" || in == "form" {
+					param.CollectionFormat = "multi"
+				}
+			} else {
+				schema := gen.simpleSchema(field.Type())
+				typeParam(&param.SimpleSchema, &param.CommonValidations, schema)
+				param.Description = schema.Description
+			}
+
+			defaultValue, hasDefault := structField.Tag.Lookup("default")
+			if hasDefault {
+				value := reflect.New(field.Type()).Elem()
+				if err := setFromStrings(value, defaultStrings(field.Type(), defaultValue)); err == nil {
+					param.WithDefault(value.Interface())
+				}
+			}
+			cons := parseConstraints(structField.Tag)
+			switch {
+			case isFileField(field.Type()):
+			case isListParam(field.Type()):
+				applyConstraints(&param.CommonValidations, &param.Format, field.Type(), cons)
+				applyConstraints(&param.Items.CommonValidations, &param.Items.Format, field.Type().Elem(), cons.items())
+			default:
+				applyConstraints(&param.CommonValidations, &param.Format, field.Type(), cons)
+			}
+			param.Required = cons.required || (!hasDefault && !isOptionalParam(field.Type()))
+			params = append(params, *param)
+			return nil
+		})
+	}
+	return params
 }
 
-func swaggerTypeFromGoType(goType reflect.Type) *openapi.Schema {
-	switch goType.Kind() {
-	case reflect.Bool:
-		return openapi.BoolProperty()
-	case reflect.Int8:
-		return openapi.Int8Property()
-	case reflect.Int16:
-		return openapi.Int16Property()
-	case reflect.Int32:
-		return openapi.Int32Property()
-	case reflect.Int, reflect.Int64:
-		return openapi.Int64Property()
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return openapi.Int64Property()
-	case reflect.Float32:
-		return openapi.Float32Property()
-	case reflect.Float64:
-		return openapi.Float64Property()
-	case reflect.String:
-		return openapi.StringProperty()
-	case reflect.Slice:
-		return openapi.ArrayProperty(swaggerTypeFromGoType(goType.Elem()))
-	case reflect.Array:
-		return openapi.ArrayProperty(swaggerTypeFromGoType(goType.Elem()))
-	case reflect.Map:
-		return openapi.MapProperty(swaggerTypeFromGoType(goType.Elem()))
-	case reflect.Struct:
-		return openapi.RefProperty(
-			fmt.Sprintf("#/definitions/%s", goType.Name()),
-		)
+// hasParameter reports whether a parameter is declared, comparing names
+// like header names.
+func hasParameter(params []openapi.Parameter, in, name string) bool {
+	for _, param := range params {
+		if param.In == in && http.CanonicalHeaderKey(param.Name) == http.CanonicalHeaderKey(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// simpleSchema describes a parameter of goType, which has no structure.
+func (g *schemaGenerator) simpleSchema(goType reflect.Type) openapi.Schema {
+	for goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+	if schema := g.knownSchema(goType); schema != nil && len(schema.Type) > 0 && schema.Type[0] != "object" && schema.Type[0] != "array" {
+		return *schema
+	}
+	if reflect.PtrTo(goType).Implements(textUnmarshalerType) {
+		return *openapi.StringProperty()
+	}
+	if schema := primitiveSchema(goType.Kind()); schema != nil {
+		return *schema
+	}
+	return *openapi.StringProperty()
+}
+
+// typeParam gives a parameter or its items the type, format, enum and
+// pattern of a schema.
+func typeParam(simple *openapi.SimpleSchema, validations *openapi.CommonValidations, schema openapi.Schema) {
+	simple.Type = schema.Type[0]
+	simple.Format = schema.Format
+	validations.Enum = schema.Enum
+	validations.Pattern = schema.Pattern
+}
+
+func setOperation(pi *openapi.PathItem, method string, op *openapi.Operation) {
+	switch method {
+	case http.MethodGet:
+		pi.Get = op
+	case http.MethodPost:
+		pi.Post = op
+	case http.MethodPut:
+		pi.Put = op
+	case http.MethodPatch:
+		pi.Patch = op
+	case http.MethodDelete:
+		pi.Delete = op
+	case http.MethodHead:
+		pi.Head = op
+	case http.MethodOptions:
+		pi.Options = op
 	}
-	return nil
 }
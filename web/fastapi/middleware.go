@@ -0,0 +1,36 @@
+package fastapi
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware wraps the handling of a route. next runs the rest of the chain
+// (dependencies, binding, the handler and writing its response) and returns
+// its error. A middleware short-circuits by returning an error without
+// calling next; the error is rendered like any handler error. Middleware
+// runs once the route's security has authenticated the request, so that
+// PrincipalFrom returns the principal of secured routes.
+type Middleware func(c *gin.Context, next func() error) error
+
+// Use adds middleware to every route of the router and its groups,
+// registered or not yet. A group's middleware runs after its parent's.
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// WithMiddleware adds middleware to a single route. It runs after the
+// router's middleware.
+func WithMiddleware(middlewares ...Middleware) RouteOption {
+	return func(rt *route) {
+		rt.middlewares = append(rt.middlewares, middlewares...)
+	}
+}
+
+func runChain(c *gin.Context, chain []Middleware, final func() error) error {
+	if len(chain) == 0 {
+		return final()
+	}
+	return chain[0](c, func() error {
+		return runChain(c, chain[1:], final)
+	})
+}
@@ -0,0 +1,85 @@
+package fastapi
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	openapi "github.com/go-openapi/spec"
+	"reflect"
+)
+
+// The Null* types wrap their database/sql counterparts, which encoding/json
+// writes as {"String": ..., "Valid": ...} objects, to encode them as
+// nullable scalars instead, e.g. "text" or null, and describe them so.
+// Their embedded sql types scan and store database columns as usual:
+//
+//	type User struct {
+//		Nickname fastapi.NullString `json:"nickname"`
+//	}
+//
+//	row.Scan(&user.Nickname)
+type (
+	NullString  struct{ sql.NullString }
+	NullInt64   struct{ sql.NullInt64 }
+	NullInt32   struct{ sql.NullInt32 }
+	NullInt16   struct{ sql.NullInt16 }
+	NullByte    struct{ sql.NullByte }
+	NullFloat64 struct{ sql.NullFloat64 }
+	NullBool    struct{ sql.NullBool }
+	NullTime    struct{ sql.NullTime }
+)
+
+func (n NullString) MarshalJSON() ([]byte, error)  { return marshalNull(n.Valid, n.String) }
+func (n NullInt64) MarshalJSON() ([]byte, error)   { return marshalNull(n.Valid, n.Int64) }
+func (n NullInt32) MarshalJSON() ([]byte, error)   { return marshalNull(n.Valid, n.Int32) }
+func (n NullInt16) MarshalJSON() ([]byte, error)   { return marshalNull(n.Valid, n.Int16) }
+func (n NullByte) MarshalJSON() ([]byte, error)    { return marshalNull(n.Valid, n.Byte) }
+func (n NullFloat64) MarshalJSON() ([]byte, error) { return marshalNull(n.Valid, n.Float64) }
+func (n NullBool) MarshalJSON() ([]byte, error)    { return marshalNull(n.Valid, n.Bool) }
+func (n NullTime) MarshalJSON() ([]byte, error)    { return marshalNull(n.Valid, n.Time) }
+
+func (n *NullString) UnmarshalJSON(data []byte) error {
+	return unmarshalNull(data, &n.Valid, &n.String)
+}
+func (n *NullInt64) UnmarshalJSON(data []byte) error { return unmarshalNull(data, &n.Valid, &n.Int64) }
+func (n *NullInt32) UnmarshalJSON(data []byte) error { return unmarshalNull(data, &n.Valid, &n.Int32) }
+func (n *NullInt16) UnmarshalJSON(data []byte) error { return unmarshalNull(data, &n.Valid, &n.Int16) }
+func (n *NullByte) UnmarshalJSON(data []byte) error  { return unmarshalNull(data, &n.Valid, &n.Byte) }
+func (n *NullFloat64) UnmarshalJSON(data []byte) error {
+	return unmarshalNull(data, &n.Valid, &n.Float64)
+}
+func (n *NullBool) UnmarshalJSON(data []byte) error { return unmarshalNull(data, &n.Valid, &n.Bool) }
+func (n *NullTime) UnmarshalJSON(data []byte) error { return unmarshalNull(data, &n.Valid, &n.Time) }
+
+func (n NullString) OpenAPISchema() openapi.Schema  { return nullSchema(n.String) }
+func (n NullInt64) OpenAPISchema() openapi.Schema   { return nullSchema(n.Int64) }
+func (n NullInt32) OpenAPISchema() openapi.Schema   { return nullSchema(n.Int32) }
+func (n NullInt16) OpenAPISchema() openapi.Schema   { return nullSchema(n.Int16) }
+func (n NullByte) OpenAPISchema() openapi.Schema    { return nullSchema(n.Byte) }
+func (n NullFloat64) OpenAPISchema() openapi.Schema { return nullSchema(n.Float64) }
+func (n NullBool) OpenAPISchema() openapi.Schema    { return nullSchema(n.Bool) }
+func (n NullTime) OpenAPISchema() openapi.Schema    { return nullSchema(n.Time) }
+
+// nullSchema describes the wrapped value, or null.
+func nullSchema(value interface{}) openapi.Schema {
+	return withExtension(newSchemaGenerator(nil).schemaFor(reflect.TypeOf(value)), "x-nullable", true)
+}
+
+func marshalNull(valid bool, value interface{}) ([]byte, error) {
+	if !valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(value)
+}
+
+func unmarshalNull(data []byte, valid *bool, value interface{}) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*valid = false
+		return nil
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return err
+	}
+	*valid = true
+	return nil
+}
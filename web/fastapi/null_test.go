@@ -0,0 +1,117 @@
+package fastapi_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"web/fastapi"
+)
+
+type nullRow struct {
+	Nickname fastapi.NullString  `json:"nickname"`
+	Age      fastapi.NullInt64   `json:"age"`
+	Score    fastapi.NullFloat64 `json:"score"`
+	Active   fastapi.NullBool    `json:"active"`
+	Seen     fastapi.NullTime    `json:"seen"`
+	Plain    sql.NullString      `json:"plain"`
+}
+
+func TestNullJSON(t *testing.T) {
+	seen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		row  nullRow
+		json string
+	}{
+		{"null", nullRow{}, `{"nickname":null,"age":null,"score":null,"active":null,"seen":null,"plain":{"String":"","Valid":false}}`},
+		{
+			"values",
+			nullRow{
+				Nickname: fastapi.NullString{NullString: sql.NullString{String: "ann", Valid: true}},
+				Age:      fastapi.NullInt64{NullInt64: sql.NullInt64{Int64: 0, Valid: true}},
+				Score:    fastapi.NullFloat64{NullFloat64: sql.NullFloat64{Float64: 1.5, Valid: true}},
+				Active:   fastapi.NullBool{NullBool: sql.NullBool{Bool: false, Valid: true}},
+				Seen:     fastapi.NullTime{NullTime: sql.NullTime{Time: seen, Valid: true}},
+			},
+			`{"nickname":"ann","age":0,"score":1.5,"active":false,"seen":"2024-01-02T03:04:05Z","plain":{"String":"","Valid":false}}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(test.row)
+			if err != nil || string(data) != test.json {
+				t.Fatalf("encoded %s, %v, want %s", data, err, test.json)
+			}
+			var decoded nullRow
+			if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded, test.row) {
+				t.Errorf("decoded %+v, %v, want %+v", decoded, err, test.row)
+			}
+		})
+	}
+}
+
+func TestNullScan(t *testing.T) {
+	var nickname fastapi.NullString
+	if err := nickname.Scan("ann"); err != nil || !nickname.Valid || nickname.String != "ann" {
+		t.Errorf("scanned %+v, %v", nickname, err)
+	}
+	if value, err := nickname.Value(); err != nil || value != "ann" {
+		t.Errorf("value %v, %v", value, err)
+	}
+	if err := nickname.Scan(nil); err != nil || nickname.Valid {
+		t.Errorf("scanned %+v, %v from NULL", nickname, err)
+	}
+}
+
+func TestNullSchemas(t *testing.T) {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodPost, "/rows", func(c *gin.Context, in nullRow) (nullRow, error) {
+		return in, nil
+	})
+
+	props := r.EmitOpenAPIDefinition().Definitions["fastapi_test.nullRow"].Properties
+	props31 := r.EmitOpenAPI31Definition().Components.Schemas["fastapi_test.nullRow"].Properties
+	tests := []struct {
+		name   string
+		types  string
+		format string
+	}{
+		{"nickname", "string", ""},
+		{"age", "integer", "int64"},
+		{"score", "number", "double"},
+		{"active", "boolean", ""},
+		{"seen", "string", "date-time"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schema := props[test.name]
+			if nullable, _ := schema.Extensions.GetBool("x-nullable"); !nullable || strings.Join(schema.Type, ",") != test.types || schema.Format != test.format {
+				t.Errorf("Swagger schema %v %s, nullable %v, want nullable %s %s", schema.Type, schema.Format, nullable, test.types, test.format)
+			}
+			schema = props31[test.name]
+			if strings.Join(schema.Type, ",") != test.types+",null" || schema.Format != test.format {
+				t.Errorf("OpenAPI 3.1 schema %v %s, want %s or null", schema.Type, schema.Format, test.types)
+			}
+		})
+	}
+	if plain := props["plain"]; plain.Ref.String() != "#/definitions/sql.NullString" {
+		t.Errorf("sql.NullString schema %s, want the object encoding/json writes", plain.Ref.String())
+	}
+}
+
+func TestNullContract(t *testing.T) {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodPost, "/rows", func(c *gin.Context, in nullRow) (nullRow, error) {
+		return in, nil
+	})
+	r.CheckContract(fastapi.ContractEnforce)
+	for _, body := range []string{`{}`, `{"nickname": null, "age": null}`, `{"nickname": "ann", "age": 3, "seen": "2024-01-02T03:04:05Z"}`} {
+		recorder := serve(r.GinHandler, http.MethodPost, "/rows", body, nil)
+		expectStatus(t, recorder, http.StatusOK)
+	}
+}
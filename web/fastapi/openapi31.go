@@ -0,0 +1,358 @@
+package fastapi
+
+import (
+	openapi "github.com/go-openapi/spec"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const jsonSchemaDialect = "https://spec.openapis.org/oas/3.1/dialect/base"
+
+type OpenAPIDocument struct {
+	OpenAPI           string                      `json:"openapi"`
+	Info              *openapi.Info               `json:"info"`
+	JSONSchemaDialect string                      `json:"jsonSchemaDialect,omitempty"`
+	Servers           []OpenAPIServer             `json:"servers,omitempty"`
+	Paths             map[string]*OpenAPIPathItem `json:"paths"`
+	Components        OpenAPIComponents           `json:"components"`
+	Tags              []openapi.Tag               `json:"tags,omitempty"`
+}
+
+type OpenAPIServer struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas         map[string]openapi.Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type OpenAPIPathItem struct {
+	Get     *OpenAPIOperation `json:"get,omitempty"`
+	Put     *OpenAPIOperation `json:"put,omitempty"`
+	Post    *OpenAPIOperation `json:"post,omitempty"`
+	Delete  *OpenAPIOperation `json:"delete,omitempty"`
+	Options *OpenAPIOperation `json:"options,omitempty"`
+	Head    *OpenAPIOperation `json:"head,omitempty"`
+	Patch   *OpenAPIOperation `json:"patch,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	Tags        []string                   `json:"tags,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	OperationID string                     `json:"operationId,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+	// WebSocket holds the message schemas of WebSocket routes.
+	WebSocket map[string]openapi.Schema `json:"x-websocket,omitempty"`
+	Sunset    string                    `json:"x-sunset,omitempty"`
+	RateLimit []rateLimitExtension      `json:"x-ratelimit,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	Name        string          `json:"name"`
+	In          string          `json:"in"`
+	Description string          `json:"description,omitempty"`
+	Required    bool            `json:"required,omitempty"`
+	Style       string          `json:"style,omitempty"`
+	Explode     *bool           `json:"explode,omitempty"`
+	Schema      *openapi.Schema `json:"schema,omitempty"`
+}
+
+type OpenAPIRequestBody struct {
+	Description string                      `json:"description,omitempty"`
+	Required    bool                        `json:"required,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIMediaType struct {
+	Schema  *openapi.Schema `json:"schema,omitempty"`
+	Example interface{}     `json:"example,omitempty"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// EmitOpenAPI31Definition describes the same routes as EmitOpenAPIDefinition
+// as an OpenAPI 3.1 document.
+func (r *Router) EmitOpenAPI31Definition() OpenAPIDocument {
+	doc := OpenAPIDocument{}
+	doc.OpenAPI = "3.1.0"
+	doc.Info = &openapi.Info{}
+	doc.Info.Title = "API generated with go-fastapi"
+	doc.Info.Version = "1.0"
+	if r.version != "" {
+		doc.Info.Version = r.version
+	}
+	doc.JSONSchemaDialect = jsonSchemaDialect
+	doc.Paths = make(map[string]*OpenAPIPathItem)
+
+	operations, definitions := r.describe()
+	for _, operation := range operations {
+		pi, present := doc.Paths[operation.path]
+		if !present {
+			pi = &OpenAPIPathItem{}
+			doc.Paths[operation.path] = pi
+		}
+		op := operation31(operation.op, operation.problems)
+		switch operation.method {
+		case http.MethodGet:
+			pi.Get = op
+		case http.MethodPost:
+			pi.Post = op
+		case http.MethodPut:
+			pi.Put = op
+		case http.MethodPatch:
+			pi.Patch = op
+		case http.MethodDelete:
+			pi.Delete = op
+		case http.MethodHead:
+			pi.Head = op
+		case http.MethodOptions:
+			pi.Options = op
+		}
+	}
+
+	doc.Components.Schemas = make(map[string]openapi.Schema)
+	for name, definition := range definitions {
+		doc.Components.Schemas[name] = schema31(definition)
+	}
+	for _, scheme := range r.securitySchemes() {
+		if doc.Components.SecuritySchemes == nil {
+			doc.Components.SecuritySchemes = make(map[string]OpenAPISecurityScheme)
+		}
+		doc.Components.SecuritySchemes[scheme.Name()] = scheme.OpenAPIDefinition()
+	}
+	doc.Tags = r.specTags()
+	return doc
+}
+
+func operation31(op *openapi.Operation, problems map[int]bool) *OpenAPIOperation {
+	converted := &OpenAPIOperation{
+		Tags:        op.Tags,
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: op.ID,
+		Deprecated:  op.Deprecated,
+		Security:    op.Security,
+		Responses:   make(map[string]OpenAPIResponse),
+	}
+	converted.Sunset, _ = op.Extensions.GetString("x-sunset")
+	converted.RateLimit, _ = op.Extensions["x-ratelimit"].([]rateLimitExtension)
+	if messages, ok := op.Extensions["x-websocket"].(map[string]openapi.Schema); ok {
+		converted.WebSocket = make(map[string]openapi.Schema, len(messages))
+		for name, schema := range messages {
+			converted.WebSocket[name] = schema31(schema)
+		}
+	}
+
+	consumes := op.Consumes
+	if len(consumes) == 0 {
+		consumes = []string{"application/json"}
+	}
+	var formParams []openapi.Parameter
+	for _, param := range op.Parameters {
+		if param.In == "body" {
+			body := &OpenAPIRequestBody{
+				Description: param.Description,
+				Required:    param.Required,
+				Content:     make(map[string]OpenAPIMediaType),
+			}
+			schema := schema31(*param.Schema)
+			example := schema.Example
+			schema.Example = nil
+			for _, mediaType := range consumes {
+				body.Content[mediaType] = OpenAPIMediaType{Schema: &schema, Example: example}
+			}
+			converted.RequestBody = body
+			continue
+		}
+		if param.In == "formData" {
+			formParams = append(formParams, param)
+			continue
+		}
+		converted.Parameters = append(converted.Parameters, parameter31(param))
+	}
+	if len(formParams) > 0 {
+		converted.RequestBody = formBody31(formParams, consumes)
+	}
+
+	var produces []string
+	for _, mediaType := range op.Produces {
+		if mediaType != problemContentType {
+			produces = append(produces, mediaType)
+		}
+	}
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+	for status, resp := range op.Responses.StatusCodeResponses {
+		converted.Responses[strconv.Itoa(status)] = response31(status, resp, produces, problems[status])
+	}
+	return converted
+}
+
+func response31(status int, resp openapi.Response, produces []string, problem bool) OpenAPIResponse {
+	converted := OpenAPIResponse{Description: resp.Description}
+	if converted.Description == "" {
+		converted.Description = http.StatusText(status)
+	}
+
+	schema := resp.Schema
+	if ref := resp.Ref.String(); ref != "" && strings.HasPrefix(ref, "#/definitions/") {
+		schema = openapi.RefSchema(ref)
+	}
+	if schema == nil {
+		return converted
+	}
+
+	mediaTypes := produces
+	if problem {
+		mediaTypes = []string{problemContentType}
+	}
+	converted.Content = make(map[string]OpenAPIMediaType)
+	for _, mediaType := range mediaTypes {
+		converted31 := schema31(*schema)
+		converted.Content[mediaType] = OpenAPIMediaType{Schema: &converted31, Example: resp.Examples[mediaType]}
+	}
+	return converted
+}
+
+// formBody31 describes Swagger 2.0 formData parameters as the properties of
+// a form request body.
+func formBody31(params []openapi.Parameter, consumes []string) *OpenAPIRequestBody {
+	schema := openapi.Schema{}
+	schema.Typed("object", "")
+	for _, param := range params {
+		property := simpleSchema31(param.SimpleSchema, param.CommonValidations)
+		if multiple, _ := param.Extensions.GetBool("x-multiple"); multiple {
+			item := property
+			property = *openapi.ArrayProperty(&item)
+		}
+		schema.SetProperty(param.Name, property)
+		if param.Required {
+			schema.Required = append(schema.Required, param.Name)
+		}
+	}
+
+	body := &OpenAPIRequestBody{Required: true, Content: make(map[string]OpenAPIMediaType)}
+	for _, mediaType := range consumes {
+		body.Content[mediaType] = OpenAPIMediaType{Schema: &schema}
+	}
+	return body
+}
+
+func parameter31(param openapi.Parameter) OpenAPIParameter {
+	converted := OpenAPIParameter{
+		Name:        param.Name,
+		In:          param.In,
+		Description: param.Description,
+		Required:    param.Required,
+	}
+
+	schema := simpleSchema31(param.SimpleSchema, param.CommonValidations)
+	converted.Schema = &schema
+
+	explode := param.CollectionFormat == "multi"
+	switch {
+	case param.Type != "array":
+	case param.In == "query" || param.In == "cookie":
+		converted.Style = "form"
+		converted.Explode = &explode
+	default:
+		converted.Style = "simple"
+	}
+	return converted
+}
+
+func simpleSchema31(simple openapi.SimpleSchema, validations openapi.CommonValidations) openapi.Schema {
+	schema := openapi.Schema{}
+	schema.Typed(simple.Type, simple.Format)
+	if simple.Type == "file" {
+		schema.Typed("string", "binary")
+	}
+	schema.Default = simple.Default
+	schema.WithValidations(openapi.SchemaValidations{CommonValidations: validations})
+	if simple.Items != nil {
+		items := simpleSchema31(simple.Items.SimpleSchema, simple.Items.CommonValidations)
+		schema.Items = &openapi.SchemaOrArray{Schema: &items}
+	}
+	return schema
+}
+
+// schema31 rewrites a Swagger 2.0 schema into its JSON Schema 2020-12 form:
+// references move under components and x-nullable becomes a "null" type.
+func schema31(schema openapi.Schema) openapi.Schema {
+	if schema.Type.Contains("file") {
+		schema.Typed("string", "binary")
+	}
+	if ref := schema.Ref.String(); ref != "" {
+		schema.Ref = openapi.MustCreateRef(
+			"#/components/schemas/" + strings.TrimPrefix(ref, "#/definitions/"),
+		)
+	}
+
+	if nullable, _ := schema.Extensions.GetBool("x-nullable"); nullable {
+		extensions := make(openapi.Extensions, len(schema.Extensions))
+		for key, value := range schema.Extensions {
+			if key != "x-nullable" {
+				extensions[key] = value
+			}
+		}
+		schema.Extensions = extensions
+		if schema.Ref.String() != "" {
+			null := openapi.Schema{}
+			null.Typed("null", "")
+			// The reference is already rewritten, so the result is final.
+			return openapi.Schema{SchemaProps: openapi.SchemaProps{AnyOf: []openapi.Schema{schema, null}}}
+		} else if len(schema.Type) > 0 {
+			schema.Type = append(append([]string{}, schema.Type...), "null")
+		}
+	}
+
+	if schema.Properties != nil {
+		props := make(map[string]openapi.Schema, len(schema.Properties))
+		for name, prop := range schema.Properties {
+			props[name] = schema31(prop)
+		}
+		schema.Properties = props
+	}
+	if schema.Items != nil {
+		items := &openapi.SchemaOrArray{}
+		if schema.Items.Schema != nil {
+			converted := schema31(*schema.Items.Schema)
+			items.Schema = &converted
+		}
+		for _, item := range schema.Items.Schemas {
+			items.Schemas = append(items.Schemas, schema31(item))
+		}
+		schema.Items = items
+	}
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		converted := schema31(*schema.AdditionalProperties.Schema)
+		schema.AdditionalProperties = &openapi.SchemaOrBool{Allows: true, Schema: &converted}
+	}
+	schema.AllOf = schemas31(schema.AllOf)
+	schema.AnyOf = schemas31(schema.AnyOf)
+	schema.OneOf = schemas31(schema.OneOf)
+	return schema
+}
+
+func schemas31(schemas []openapi.Schema) []openapi.Schema {
+	if schemas == nil {
+		return nil
+	}
+	converted := make([]openapi.Schema, 0, len(schemas))
+	for _, schema := range schemas {
+		converted = append(converted, schema31(schema))
+	}
+	return converted
+}
@@ -0,0 +1,136 @@
+package fastapi_test
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"web/fastapi"
+)
+
+type owner struct {
+	Name string `json:"name"`
+}
+
+type pet struct {
+	Name  string  `json:"name" validate:"required"`
+	Tag   *string `json:"tag"`
+	Owner *owner  `json:"owner"`
+}
+
+type petInput struct {
+	Tags  []string `query:"tag"`
+	Trace []string `header:"X-Trace"`
+	Name  string   `json:"name" validate:"required"`
+}
+
+func petsRouter() *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodPost, "/pets", func(c *gin.Context, in petInput) (pet, error) {
+		return pet{Name: in.Name}, nil
+	}, fastapi.RequestExample(petInput{Name: "rex"}))
+	return r
+}
+
+// document returns the 3.1 document of r as generic JSON.
+func document(t *testing.T, r *fastapi.Router) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(r.EmitOpenAPI31Definition())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "#/definitions/") || strings.Contains(string(data), "x-nullable") {
+		t.Errorf("the document has Swagger 2.0 constructs: %s", data)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// at follows a path of object keys and array indexes through a JSON value.
+func at(value interface{}, path ...interface{}) interface{} {
+	for _, key := range path {
+		switch key := key.(type) {
+		case string:
+			object, _ := value.(map[string]interface{})
+			value = object[key]
+		case int:
+			array, _ := value.([]interface{})
+			if key >= len(array) {
+				return nil
+			}
+			value = array[key]
+		}
+	}
+	return value
+}
+
+func TestOpenAPI31(t *testing.T) {
+	doc := document(t, petsRouter())
+	op := at(doc, "paths", "/pets", "post")
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"version", doc["openapi"], "3.1.0"},
+		{"dialect", doc["jsonSchemaDialect"], "https://spec.openapis.org/oas/3.1/dialect/base"},
+		{"query list style", at(op, "parameters", 0, "style"), "form"},
+		{"query list explode", at(op, "parameters", 0, "explode"), true},
+		{"query list items", at(op, "parameters", 0, "schema", "items", "type"), "string"},
+		{"header list style", at(op, "parameters", 1, "style"), "simple"},
+		{"body is not a parameter", at(op, "parameters", 2), nil},
+		{"body required", at(op, "requestBody", "required"), true},
+		{"body example", at(op, "requestBody", "content", "application/json", "example", "name"), "rex"},
+		{"body schema", at(op, "requestBody", "content", "application/json", "schema", "$ref"), "#/components/schemas/fastapi_test.petInput"},
+		{"response schema", at(op, "responses", "200", "content", "application/json", "schema", "properties", "response", "$ref"), "#/components/schemas/fastapi_test.pet"},
+		{"problem schema", at(op, "responses", "422", "content", "application/problem+json", "schema", "$ref"), "#/components/schemas/fastapi.Problem"},
+		{"problems only as problem+json", at(op, "responses", "422", "content", "application/json"), nil},
+		{"nullable scalar", at(doc, "components", "schemas", "fastapi_test.pet", "properties", "tag", "type"), []interface{}{"string", "null"}},
+		{"nullable reference", at(doc, "components", "schemas", "fastapi_test.pet", "properties", "owner", "anyOf"), []interface{}{
+			map[string]interface{}{"$ref": "#/components/schemas/fastapi_test.owner"},
+			map[string]interface{}{"type": "null"},
+		}},
+		{"required", at(doc, "components", "schemas", "fastapi_test.pet", "required"), []interface{}{"name"}},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("%s: %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestOpenAPI31MatchesSwagger(t *testing.T) {
+	r := methodsRouter()
+	swagger := r.EmitOpenAPIDefinition()
+	doc := r.EmitOpenAPI31Definition()
+	if len(doc.Paths) != len(swagger.Paths.Paths) {
+		t.Fatalf("%d paths, want the %d of Swagger", len(doc.Paths), len(swagger.Paths.Paths))
+	}
+	for path, item := range swagger.Paths.Paths {
+		converted := doc.Paths[path]
+		if converted == nil {
+			t.Errorf("%s is missing", path)
+			continue
+		}
+		pairs := map[string][2]bool{
+			"GET":    {item.Get != nil, converted.Get != nil},
+			"PUT":    {item.Put != nil, converted.Put != nil},
+			"POST":   {item.Post != nil, converted.Post != nil},
+			"PATCH":  {item.Patch != nil, converted.Patch != nil},
+			"DELETE": {item.Delete != nil, converted.Delete != nil},
+		}
+		for method, pair := range pairs {
+			if pair[0] != pair[1] {
+				t.Errorf("%s %s: in Swagger %v, in 3.1 %v", method, path, pair[0], pair[1])
+			}
+		}
+		if item.Get != nil && converted.Get.OperationID != item.Get.ID {
+			t.Errorf("GET %s operationId %q, want %q", path, converted.Get.OperationID, item.Get.ID)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package fastapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	openapi "github.com/go-openapi/spec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OffsetParams binds offset pagination, e.g. ?offset=40&limit=20. List
+// endpoints embed the parameters they support in their input and answer with
+// a page:
+//
+//	type UserFields struct{}
+//
+//	func (UserFields) Fields() []string { return []string{"name", "created_at"} }
+//
+//	type ListUsers struct {
+//		fastapi.OffsetParams
+//		fastapi.Sorted[UserFields]
+//		fastapi.Filtered[UserFields]
+//	}
+//
+//	func listUsers(c *gin.Context, in ListUsers) (fastapi.OffsetPage[User], error) {
+//		users, total := db.Users(in.Sort, in.Filter, in.Offset, in.Limit)
+//		return fastapi.NewOffsetPage(c, in.OffsetParams, users, total), nil
+//	}
+type OffsetParams struct {
+	Offset int `query:"offset" default:"0" validate:"min=0"`
+	Limit  int `query:"limit" default:"20" validate:"min=1,max=100"`
+}
+
+// CursorParams binds cursor pagination, e.g. ?cursor=eyJpZCI6NDJ9&limit=20,
+// the cursor being the NextCursor of the previous page.
+type CursorParams struct {
+	Cursor string `query:"cursor" default:""`
+	Limit  int    `query:"limit" default:"20" validate:"min=1,max=100"`
+}
+
+// DecodeCursor decodes the cursor into v, an empty cursor leaving v alone.
+func (p CursorParams) DecodeCursor(v interface{}) error {
+	if p.Cursor == "" {
+		return nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		verr := &ValidationError{}
+		verr.add("query", "cursor", "invalid cursor")
+		return verr
+	}
+	return nil
+}
+
+// EncodeCursor returns an opaque cursor holding v, typically the sort key of
+// the last item of a page.
+func EncodeCursor(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic("Cursor cannot be encoded: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+type OffsetPage[T any] struct {
+	Items  []T `json:"items"`
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+	// Total counts the items of every page.
+	Total int `json:"total"`
+}
+
+type CursorPage[T any] struct {
+	Items []T `json:"items"`
+	// NextCursor fetches the next page, empty on the last one.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewOffsetPage returns a page of items, setting the Link header to the
+// first, previous, next and last pages.
+func NewOffsetPage[T any](c *gin.Context, params OffsetParams, items []T, total int) OffsetPage[T] {
+	if items == nil {
+		items = []T{}
+	}
+	limit := strconv.Itoa(params.Limit)
+	addPageLink(c, "first", map[string]string{"offset": "0", "limit": limit})
+	if params.Offset > 0 {
+		addPageLink(c, "prev", map[string]string{"offset": strconv.Itoa(max(0, params.Offset-params.Limit)), "limit": limit})
+	}
+	if params.Offset+params.Limit < total {
+		addPageLink(c, "next", map[string]string{"offset": strconv.Itoa(params.Offset + params.Limit), "limit": limit})
+	}
+	if total > 0 {
+		addPageLink(c, "last", map[string]string{"offset": strconv.Itoa((total - 1) / params.Limit * params.Limit), "limit": limit})
+	}
+	return OffsetPage[T]{Items: items, Offset: params.Offset, Limit: params.Limit, Total: total}
+}
+
+// NewCursorPage returns a page of items, setting the Link header to the next
+// page unless nextCursor is empty.
+func NewCursorPage[T any](c *gin.Context, params CursorParams, items []T, nextCursor string) CursorPage[T] {
+	if items == nil {
+		items = []T{}
+	}
+	if nextCursor != "" {
+		addPageLink(c, "next", map[string]string{"cursor": nextCursor, "limit": strconv.Itoa(params.Limit)})
+	}
+	return CursorPage[T]{Items: items, NextCursor: nextCursor}
+}
+
+// addPageLink links to the request's URL with the query parameters in set
+// replaced.
+func addPageLink(c *gin.Context, rel string, set map[string]string) {
+	target := *c.Request.URL
+	query := target.Query()
+	for name, value := range set {
+		query.Set(name, value)
+	}
+	target.RawQuery = query.Encode()
+	c.Writer.Header().Add("Link", "<"+target.RequestURI()+`>; rel="`+rel+`"`)
+}
+
+// FieldSet lists the fields a list endpoint sorts or filters by, as the type
+// parameter of Sort and Filter.
+type FieldSet interface {
+	Fields() []string
+}
+
+func allowedFields[F FieldSet]() []string {
+	var fields F
+	return fields.Fields()
+}
+
+func isAllowedField[F FieldSet](name string) bool {
+	for _, field := range allowedFields[F]() {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldsPattern[F FieldSet]() string {
+	quoted := make([]string, 0, len(allowedFields[F]()))
+	for _, field := range allowedFields[F]() {
+		quoted = append(quoted, regexp.QuoteMeta(field))
+	}
+	return "(" + strings.Join(quoted, "|") + ")"
+}
+
+// Sorted binds the sort order of a list, e.g. ?sort=-created_at,name.
+type Sorted[F FieldSet] struct {
+	Sort Sort[F] `query:"sort" default:""`
+}
+
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// Sort is a list of fields to sort by, descending when prefixed with "-".
+type Sort[F FieldSet] []SortField
+
+func (s *Sort[F]) UnmarshalText(text []byte) error {
+	var sort Sort[F]
+	for _, field := range splitList(string(text)) {
+		name := strings.TrimPrefix(field, "-")
+		if !isAllowedField[F](name) {
+			return fmt.Errorf("cannot sort by %q", name)
+		}
+		sort = append(sort, SortField{Field: name, Descending: name != field})
+	}
+	*s = sort
+	return nil
+}
+
+func (s Sort[F]) MarshalText() ([]byte, error) {
+	fields := make([]string, len(s))
+	for i, field := range s {
+		fields[i] = field.Field
+		if field.Descending {
+			fields[i] = "-" + field.Field
+		}
+	}
+	return []byte(strings.Join(fields, ",")), nil
+}
+
+func (Sort[F]) OpenAPISchema() openapi.Schema {
+	field := "-?" + fieldsPattern[F]()
+	schema := openapi.StringProperty().
+		WithPattern("^(" + field + "(," + field + ")*)?$").
+		WithDescription("Comma separated fields to sort by, descending when prefixed with -: " + strings.Join(allowedFields[F](), ", "))
+	return *schema
+}
+
+// Filtered binds the filters of a list, e.g. ?filter=status:active&filter=age:gte:18.
+type Filtered[F FieldSet] struct {
+	Filter []Filter[F] `query:"filter"`
+}
+
+// filterOperators are the operators of filters, eq when left out. The value
+// of in lists values separated by "|".
+var filterOperators = []string{"eq", "ne", "lt", "lte", "gt", "gte", "in", "contains"}
+
+// Filter restricts a list to the items whose field compares to value with
+// the operator Op, written field:value or field:op:value.
+type Filter[F FieldSet] struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// Values splits the value of an in filter.
+func (f Filter[F]) Values() []string {
+	return strings.Split(f.Value, "|")
+}
+
+func (f *Filter[F]) UnmarshalText(text []byte) error {
+	field, value, found := strings.Cut(string(text), ":")
+	if !found {
+		return fmt.Errorf("expected field:value or field:op:value")
+	}
+	if !isAllowedField[F](field) {
+		return fmt.Errorf("cannot filter by %q", field)
+	}
+	op := "eq"
+	if candidate, rest, found := strings.Cut(value, ":"); found {
+		for _, known := range filterOperators {
+			if candidate == known {
+				op, value = candidate, rest
+			}
+		}
+	}
+	*f = Filter[F]{Field: field, Op: op, Value: value}
+	return nil
+}
+
+func (f Filter[F]) MarshalText() ([]byte, error) {
+	return []byte(f.Field + ":" + f.Op + ":" + f.Value), nil
+}
+
+func (Filter[F]) OpenAPISchema() openapi.Schema {
+	schema := openapi.StringProperty().
+		WithPattern("^" + fieldsPattern[F]() + ":((" + strings.Join(filterOperators, "|") + "):)?").
+		WithDescription("field:value or field:op:value, op being one of " + strings.Join(filterOperators, ", ") +
+			" and fields one of " + strings.Join(allowedFields[F](), ", "))
+	return *schema
+}
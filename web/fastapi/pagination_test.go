@@ -0,0 +1,227 @@
+package fastapi_test
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"testing"
+	"web/fastapi"
+	"web/fastapi/fastapitest"
+)
+
+type userFields struct{}
+
+func (userFields) Fields() []string { return []string{"name", "age", "created_at"} }
+
+type listUsers struct {
+	fastapi.OffsetParams
+	fastapi.Sorted[userFields]
+	fastapi.Filtered[userFields]
+}
+
+type scrollUsers struct {
+	fastapi.CursorParams
+}
+
+type userCursor struct {
+	After int `json:"after"`
+}
+
+type listing struct {
+	Sort    []fastapi.SortField `json:"sort"`
+	Filters []string            `json:"filters"`
+}
+
+// users are the names u00 to u44.
+var users = func() []string {
+	names := make([]string, 45)
+	for i := range names {
+		names[i] = fmt.Sprintf("u%02d", i)
+	}
+	return names
+}()
+
+func paginationRouter() *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodGet, "/users", func(c *gin.Context, in listUsers) (fastapi.OffsetPage[string], error) {
+		end := min(in.Offset+in.Limit, len(users))
+		return fastapi.NewOffsetPage(c, in.OffsetParams, users[min(in.Offset, end):end], len(users)), nil
+	})
+	r.Handle(http.MethodGet, "/listing", func(c *gin.Context, in listUsers) (listing, error) {
+		out := listing{Sort: in.Sort}
+		for _, filter := range in.Filter {
+			out.Filters = append(out.Filters, fmt.Sprintf("%s %s %q", filter.Field, filter.Op, filter.Values()))
+		}
+		return out, nil
+	})
+	r.Handle(http.MethodGet, "/scroll", func(c *gin.Context, in scrollUsers) (fastapi.CursorPage[string], error) {
+		var cursor userCursor
+		if err := in.DecodeCursor(&cursor); err != nil {
+			return fastapi.CursorPage[string]{}, err
+		}
+		end := min(cursor.After+in.Limit, len(users))
+		next := ""
+		if end < len(users) {
+			next = fastapi.EncodeCursor(userCursor{After: end})
+		}
+		return fastapi.NewCursorPage(c, in.CursorParams, users[cursor.After:end], next), nil
+	})
+	return r
+}
+
+func TestOffsetPage(t *testing.T) {
+	tests := []struct {
+		target string
+		first  string
+		last   string
+		links  []string
+	}{
+		{"/users", "u00", "u19", []string{
+			`</users?limit=20&offset=0>; rel="first"`,
+			`</users?limit=20&offset=20>; rel="next"`,
+			`</users?limit=20&offset=40>; rel="last"`,
+		}},
+		{"/users?offset=15&limit=10&sort=name", "u15", "u24", []string{
+			`</users?limit=10&offset=0&sort=name>; rel="first"`,
+			`</users?limit=10&offset=5&sort=name>; rel="prev"`,
+			`</users?limit=10&offset=25&sort=name>; rel="next"`,
+			`</users?limit=10&offset=40&sort=name>; rel="last"`,
+		}},
+		{"/users?offset=40&limit=10", "u40", "u44", []string{
+			`</users?limit=10&offset=0>; rel="first"`,
+			`</users?limit=10&offset=30>; rel="prev"`,
+			`</users?limit=10&offset=40>; rel="last"`,
+		}},
+		{"/users?offset=5&limit=100", "u05", "u44", []string{
+			`</users?limit=100&offset=0>; rel="first"`,
+			`</users?limit=100&offset=0>; rel="prev"`,
+			`</users?limit=100&offset=0>; rel="last"`,
+		}},
+	}
+	r := paginationRouter()
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodGet, test.target, "", nil)
+			expectStatus(t, recorder, http.StatusOK)
+			var page fastapi.OffsetPage[string]
+			decode(t, recorder, &page)
+			if page.Total != len(users) || page.Items[0] != test.first || page.Items[len(page.Items)-1] != test.last {
+				t.Errorf("page %+v, want %s to %s of %d", page, test.first, test.last, len(users))
+			}
+			if links := recorder.Header().Values("Link"); !reflect.DeepEqual(links, test.links) {
+				t.Errorf("links %q, want %q", links, test.links)
+			}
+		})
+	}
+}
+
+func TestOffsetPageEmpty(t *testing.T) {
+	var page fastapi.OffsetPage[string]
+	recorder := serve(func(c *gin.Context) {
+		page = fastapi.NewOffsetPage[string](c, fastapi.OffsetParams{Limit: 20}, nil, 0)
+	}, http.MethodGet, "/users", "", nil)
+	if page.Items == nil || len(page.Items) != 0 {
+		t.Errorf("items %#v, want an empty list", page.Items)
+	}
+	if links := recorder.Header().Values("Link"); len(links) != 1 {
+		t.Errorf("links %q, want the first page only", links)
+	}
+}
+
+func TestCursorPage(t *testing.T) {
+	r := paginationRouter()
+	client := fastapitest.NewClient(t, r)
+	var seen []string
+	params := fastapi.CursorParams{Limit: 20}
+	for pages := 0; pages < 10; pages++ {
+		page := fastapitest.MustCall[fastapi.CursorPage[string]](client, http.MethodGet, "/scroll", scrollUsers{params})
+		seen = append(seen, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		params.Cursor = page.NextCursor
+	}
+	if !reflect.DeepEqual(seen, users) {
+		t.Errorf("scrolled %v, want every user once", seen)
+	}
+
+	recorder := serve(r.GinHandler, http.MethodGet, "/scroll?limit=40", "", nil)
+	want := `</scroll?cursor=` + fastapi.EncodeCursor(userCursor{After: 40}) + `&limit=40>; rel="next"`
+	if link := recorder.Header().Get("Link"); link != want {
+		t.Errorf("link %q, want %q", link, want)
+	}
+}
+
+func TestListing(t *testing.T) {
+	client := fastapitest.NewClient(t, paginationRouter())
+	in := listUsers{OffsetParams: fastapi.OffsetParams{Limit: 20}}
+	in.Sort = fastapi.Sort[userFields]{{Field: "created_at", Descending: true}, {Field: "name"}}
+	in.Filter = []fastapi.Filter[userFields]{
+		{Field: "name", Op: "eq", Value: "alice"},
+		{Field: "age", Op: "gte", Value: "18"},
+		{Field: "name", Op: "in", Value: "a|b"},
+	}
+	got := fastapitest.MustCall[listing](client, http.MethodGet, "/listing", in)
+	want := listing{
+		Sort:    []fastapi.SortField{{Field: "created_at", Descending: true}, {Field: "name"}},
+		Filters: []string{`name eq ["alice"]`, `age gte ["18"]`, `name in ["a" "b"]`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("listed %+v, want %+v", got, want)
+	}
+
+	recorder := serve(paginationRouter().GinHandler, http.MethodGet, "/listing?filter=name:alice&filter=name:x:y", "", nil)
+	expectStatus(t, recorder, http.StatusOK)
+	got = listing{}
+	decode(t, recorder, &got)
+	if want := []string{`name eq ["alice"]`, `name eq ["x:y"]`}; !reflect.DeepEqual(got.Filters, want) {
+		t.Errorf("filters %q, want %q", got.Filters, want)
+	}
+}
+
+func TestListingInvalid(t *testing.T) {
+	tests := []struct {
+		target string
+		param  string
+	}{
+		{"/users?limit=0", "limit"},
+		{"/users?limit=101", "limit"},
+		{"/users?offset=-1", "offset"},
+		{"/users?sort=password", "sort"},
+		{"/users?sort=-", "sort"},
+		{"/users?filter=password:x", "filter"},
+		{"/users?filter=name", "filter"},
+		{"/scroll?cursor=not*base64", "cursor"},
+		{"/scroll?cursor=bm90IGpzb24", "cursor"},
+	}
+	r := paginationRouter()
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodGet, test.target, "", nil)
+			expectStatus(t, recorder, http.StatusUnprocessableEntity)
+			details, _ := problem(t, recorder).Details.([]interface{})
+			if len(details) != 1 || details[0].(map[string]interface{})["path"] != test.param {
+				t.Errorf("details %v, want an error for %s", details, test.param)
+			}
+		})
+	}
+}
+
+func TestPaginationSpec(t *testing.T) {
+	op := paginationRouter().EmitOpenAPIDefinition().Paths.Paths["/users"].Get
+	params := make(map[string]int)
+	for i, param := range op.Parameters {
+		params[param.Name] = i
+	}
+	limit := op.Parameters[params["limit"]]
+	if limit.Minimum == nil || *limit.Minimum != 1 || limit.Maximum == nil || *limit.Maximum != 100 || limit.Default != 20 {
+		t.Errorf("limit %+v, want 1 to 100, 20 by default", limit.CommonValidations)
+	}
+	if sort := op.Parameters[params["sort"]]; sort.Type != "string" || sort.Pattern == "" {
+		t.Errorf("sort %s %q, want a string pattern", sort.Type, sort.Pattern)
+	}
+	if filter := op.Parameters[params["filter"]]; filter.Type != "array" || filter.CollectionFormat != "multi" || filter.Items == nil || filter.Items.Pattern == "" {
+		t.Errorf("filter %+v, want repeated strings with a pattern", filter.SimpleSchema)
+	}
+}
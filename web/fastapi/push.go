@@ -0,0 +1,146 @@
+package fastapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const defaultHeartbeat = 15 * time.Second
+
+// Event is one server-sent event. Data is sent as JSON; ID, when set, is
+// what clients send back as Last-Event-ID when they reconnect.
+type Event[T any] struct {
+	ID    string
+	Event string
+	Data  T
+	// Retry tells clients how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// EventsHandlerFunc starts a stream of events. The stream ends when the
+// handler closes the channel or the client goes away. Goroutines sending
+// events must not use c, which is reused once the stream ends, but should
+// stop once the context from c.Request.Context() is done.
+type EventsHandlerFunc[In, T any] func(c *gin.Context, in In) (<-chan Event[T], error)
+
+// Events registers a GET route serving the handler's events as
+// text/event-stream. The route goes through the router's security,
+// middleware and input binding like any other.
+func Events[In, T any](r *Router, path string, handler EventsHandlerFunc[In, T], opts ...RouteOption) {
+	rt := &route{
+		method:     http.MethodGet,
+		path:       path,
+		inputType:  reflect.TypeOf((*In)(nil)).Elem(),
+		outputType: eventStreamType,
+		events:     reflect.TypeOf((*T)(nil)).Elem(),
+		produces:   []string{"text/event-stream"},
+		heartbeat:  defaultHeartbeat,
+	}
+	rt.invoke = func(c *gin.Context, inputPtr interface{}) (interface{}, error) {
+		events, err := handler(c, *inputPtr.(*In))
+		if err != nil {
+			return nil, err
+		}
+		return eventStream{serve: func(c *gin.Context) error {
+			return serveEvents(c, events, rt.heartbeat)
+		}}, nil
+	}
+	r.addRoute(rt, opts)
+}
+
+// Heartbeat sets how often idle event streams and WebSockets are kept
+// alive, 15 seconds by default. Zero disables heartbeats.
+func Heartbeat(interval time.Duration) RouteOption {
+	return func(rt *route) {
+		rt.heartbeat = interval
+	}
+}
+
+// LastEventID returns the ID of the last event a reconnecting client
+// received, from the Last-Event-ID header or the lastEventId query
+// parameter used by EventSource polyfills.
+func LastEventID(c *gin.Context) string {
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("lastEventId")
+}
+
+type eventStream struct {
+	serve func(c *gin.Context) error
+}
+
+var eventStreamType = reflect.TypeOf(eventStream{})
+
+func (s eventStream) write(c *gin.Context, _ int) error {
+	return s.serve(c)
+}
+
+func serveEvents[T any](c *gin.Context, events <-chan Event[T], heartbeat time.Duration) error {
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	var ticks <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ticks:
+			if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case event, open := <-events:
+			if !open {
+				return nil
+			}
+			frame, err := formatEvent(event)
+			if err != nil {
+				return err
+			}
+			if _, err := c.Writer.WriteString(frame); err != nil {
+				return nil
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func formatEvent[T any](event Event[T]) (string, error) {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return "", err
+	}
+	var frame strings.Builder
+	if event.ID != "" {
+		fmt.Fprintf(&frame, "id: %s\n", singleLine(event.ID))
+	}
+	if event.Event != "" {
+		fmt.Fprintf(&frame, "event: %s\n", singleLine(event.Event))
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(&frame, "retry: %d\n", event.Retry.Milliseconds())
+	}
+	fmt.Fprintf(&frame, "data: %s\n\n", data)
+	return frame.String(), nil
+}
+
+// singleLine keeps a field from breaking out of its line in the stream.
+func singleLine(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
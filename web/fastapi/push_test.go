@@ -0,0 +1,156 @@
+package fastapi_test
+
+import (
+	"bufio"
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+	"web/fastapi"
+)
+
+type tick struct {
+	N int `json:"n"`
+}
+
+type ticksInput struct {
+	Count int `query:"count" default:"-1" validate:"min=-1"`
+}
+
+// pushRouter streams count ticks, or ticks until the client goes away when
+// count is -1, resuming after the Last-Event-ID. stopped is closed once an
+// endless stream stops.
+func pushRouter(stopped chan struct{}) *fastapi.Router {
+	r := fastapi.NewRouter()
+	fastapi.Events(r, "/ticks", func(c *gin.Context, in ticksInput) (<-chan fastapi.Event[tick], error) {
+		ctx := c.Request.Context()
+		first := 0
+		if last := fastapi.LastEventID(c); last != "" {
+			n, err := strconv.Atoi(last)
+			if err != nil {
+				return nil, fastapi.NewError(http.StatusBadRequest, "invalid_event_id", "invalid Last-Event-ID")
+			}
+			first = n + 1
+		}
+		events := make(chan fastapi.Event[tick])
+		go func() {
+			defer close(events)
+			for n := first; in.Count < 0 || n < first+in.Count; n++ {
+				event := fastapi.Event[tick]{ID: strconv.Itoa(n), Event: "tick", Data: tick{N: n}}
+				if n == 0 {
+					event.Retry = 2 * time.Second
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					close(stopped)
+					return
+				}
+			}
+		}()
+		return events, nil
+	})
+	fastapi.Events(r, "/idle", func(c *gin.Context, in struct{}) (<-chan fastapi.Event[tick], error) {
+		return make(chan fastapi.Event[tick]), nil
+	}, fastapi.Heartbeat(10*time.Millisecond))
+	return r
+}
+
+// listen serves r on a local server closed with the test.
+func listen(t *testing.T, r *fastapi.Router) *httptest.Server {
+	engine := gin.New()
+	engine.Any("/*path", r.GinHandler)
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+		body   string
+	}{
+		{"stream", "/ticks?count=2", nil, "id: 0\nevent: tick\nretry: 2000\ndata: {\"n\":0}\n\nid: 1\nevent: tick\ndata: {\"n\":1}\n\n"},
+		{"resumed", "/ticks?count=1", http.Header{"Last-Event-Id": {"4"}}, "id: 5\nevent: tick\ndata: {\"n\":5}\n\n"},
+		{"resumed by polyfills", "/ticks?count=1&lastEventId=6", nil, "id: 7\nevent: tick\ndata: {\"n\":7}\n\n"},
+		{"empty", "/ticks?count=0", nil, ""},
+	}
+	r := pushRouter(nil)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodGet, test.target, "", test.header)
+			expectStatus(t, recorder, http.StatusOK)
+			if contentType := recorder.Header().Get("Content-Type"); contentType != "text/event-stream" {
+				t.Errorf("Content-Type %q, want text/event-stream", contentType)
+			}
+			if cacheControl := recorder.Header().Get("Cache-Control"); cacheControl != "no-cache" {
+				t.Errorf("Cache-Control %q, want no-cache", cacheControl)
+			}
+			if recorder.Body.String() != test.body {
+				t.Errorf("body %q, want %q", recorder.Body.String(), test.body)
+			}
+		})
+	}
+}
+
+func TestEventsErrors(t *testing.T) {
+	r := pushRouter(nil)
+	recorder := serve(r.GinHandler, http.MethodGet, "/ticks?count=-2", "", nil)
+	expectStatus(t, recorder, http.StatusUnprocessableEntity)
+	recorder = serve(r.GinHandler, http.MethodGet, "/ticks", "", http.Header{"Last-Event-Id": {"x"}})
+	expectStatus(t, recorder, http.StatusBadRequest)
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Content-Type %q, want application/problem+json", contentType)
+	}
+	expectStatus(t, serve(r.GinHandler, http.MethodPost, "/ticks", "", nil), http.StatusMethodNotAllowed)
+}
+
+// readStream opens target on server and returns its lines, canceling the
+// request when the test ends.
+func readStream(t *testing.T, server *httptest.Server, target string) (*bufio.Scanner, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+target, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return bufio.NewScanner(resp.Body), cancel
+}
+
+func TestEventsStopWithClient(t *testing.T) {
+	stopped := make(chan struct{})
+	lines, cancel := readStream(t, listen(t, pushRouter(stopped)), "/ticks")
+	if !lines.Scan() || lines.Text() != "id: 0" {
+		t.Fatalf("read %q, want the first event", lines.Text())
+	}
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("the stream went on after the client went away")
+	}
+}
+
+func TestEventsHeartbeat(t *testing.T) {
+	lines, _ := readStream(t, listen(t, pushRouter(nil)), "/idle")
+	if !lines.Scan() || lines.Text() != ": heartbeat" {
+		t.Errorf("read %q, want a heartbeat comment", lines.Text())
+	}
+}
+
+func TestEventsSpec(t *testing.T) {
+	op := pushRouter(nil).EmitOpenAPIDefinition().Paths.Paths["/ticks"].Get
+	if len(op.Produces) == 0 || op.Produces[0] != "text/event-stream" {
+		t.Errorf("produces %v, want text/event-stream", op.Produces)
+	}
+	if schema := op.Responses.StatusCodeResponses[http.StatusOK].Schema; schema == nil || schema.Ref.String() != "#/definitions/fastapi_test.tick" {
+		t.Errorf("event schema %v, want the tick definition", schema)
+	}
+}
@@ -0,0 +1,290 @@
+package fastapi
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type RateAlgorithm int
+
+const (
+	// TokenBucket allows bursts of up to Requests, refilled evenly over the
+	// window.
+	TokenBucket RateAlgorithm = iota
+	// SlidingWindow allows Requests in any window, estimated from the counts
+	// of the current and previous fixed windows.
+	SlidingWindow
+)
+
+func (a RateAlgorithm) String() string {
+	if a == SlidingWindow {
+		return "sliding-window"
+	}
+	return "token-bucket"
+}
+
+// RateLimit allows a number of requests per window. By default the budget is
+// the route's, shared by every caller.
+type RateLimit struct {
+	Requests  int
+	Window    time.Duration
+	Algorithm RateAlgorithm
+	// PerPrincipal gives every authenticated principal a budget of its own,
+	// and every client address for anonymous requests, see UseClientIP.
+	PerPrincipal bool
+	// Name shares the budget between the routes with a limit of that name,
+	// e.g. a quota for a group set with its Defaults.
+	Name string
+}
+
+// RateLimited limits the rate of requests to the route. Requests over any of
+// the limits are answered 429 with Retry-After, and every response carries
+// the RateLimit-* headers of the limit closest to being exhausted. Limits
+// apply after authentication, so that they can be counted per principal.
+func RateLimited(limits ...RateLimit) RouteOption {
+	for _, limit := range limits {
+		if limit.Requests <= 0 || limit.Window <= 0 {
+			panic("Rate limit needs positive requests and window")
+		}
+	}
+	return func(rt *route) {
+		rt.rateLimits = append(rt.rateLimits, limits...)
+	}
+}
+
+// RateLimitStore keeps the budgets of rate limits.
+type RateLimitStore interface {
+	// Take spends a request from the budget at key if it has one left.
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset is the time until the budget is whole again, RetryAfter the time
+	// until a denied request would be allowed.
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// rateLimiting holds the store of a router's rate limits, shared by the
+// router and its groups.
+type rateLimiting struct {
+	store    RateLimitStore
+	clientIP func(c *gin.Context) string
+}
+
+// UseRateLimitStore keeps the budgets of the router's rate limits in store,
+// e.g. a RedisRateLimitStore shared by every instance of a service. Budgets
+// are kept in memory by default.
+func (r *Router) UseRateLimitStore(store RateLimitStore) {
+	r.rateLimits.store = store
+}
+
+// UseClientIP sets the client address anonymous requests are counted by in
+// per-principal limits. It is the connection's RemoteIP by default, that of
+// the proxy for services behind one: those use (*gin.Context).ClientIP with
+// the engine's TrustedProxies set to their proxies, as gin otherwise trusts
+// the X-Forwarded-For header of any client.
+func (r *Router) UseClientIP(clientIP func(c *gin.Context) string) {
+	r.rateLimits.clientIP = clientIP
+}
+
+// limitRate spends a request from every limit of the route, setting the
+// RateLimit-* headers. Store failures are logged and let requests through.
+func (r *Router) limitRate(c *gin.Context, rt *route) error {
+	var reported *RateLimit
+	var report RateLimitResult
+	for i := range rt.rateLimits {
+		limit := &rt.rateLimits[i]
+		result, err := r.rateLimits.store.Take(c.Request.Context(), rateLimitKey(c, rt, *limit, r.rateLimits.clientIP), *limit)
+		if err != nil {
+			log.Printf("fastapi: rate limit of %s %s not applied: %v", rt.method, rt.path, err)
+			continue
+		}
+		switch {
+		case reported == nil,
+			!result.Allowed && (report.Allowed || result.RetryAfter > report.RetryAfter),
+			result.Allowed && report.Allowed && result.Remaining < report.Remaining:
+			reported, report = limit, result
+		}
+	}
+	if reported == nil {
+		return nil
+	}
+
+	header := c.Writer.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(reported.Requests))
+	header.Set("RateLimit-Remaining", strconv.Itoa(report.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(report.Reset)))
+	header.Set("RateLimit-Policy", strconv.Itoa(reported.Requests)+";w="+strconv.Itoa(ceilSeconds(reported.Window)))
+	if report.Allowed {
+		return nil
+	}
+	header.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(report.RetryAfter))))
+	return NewError(http.StatusTooManyRequests, "rate_limited", "rate limit exceeded")
+}
+
+func rateLimitKey(c *gin.Context, rt *route, limit RateLimit, clientIP func(c *gin.Context) string) string {
+	key := "route:" + rt.method + " " + rt.path
+	if limit.Name != "" {
+		key = "name:" + limit.Name
+	}
+	key += ":" + limit.Algorithm.String()
+	if limit.PerPrincipal {
+		if principal := PrincipalFrom(c); principal != nil {
+			key += ":principal:" + principal.Scheme + ":" + principal.Subject
+		} else {
+			key += ":ip:" + clientIP(c)
+		}
+	}
+	return key
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// rateLimitExtension documents a limit in the x-ratelimit extension.
+type rateLimitExtension struct {
+	Name      string `json:"name,omitempty"`
+	Requests  int    `json:"requests"`
+	Window    int    `json:"window"`
+	Algorithm string `json:"algorithm"`
+	Per       string `json:"per"`
+}
+
+func rateLimitExtensions(limits []RateLimit) []rateLimitExtension {
+	extensions := make([]rateLimitExtension, len(limits))
+	for i, limit := range limits {
+		extensions[i] = rateLimitExtension{
+			Name:      limit.Name,
+			Requests:  limit.Requests,
+			Window:    ceilSeconds(limit.Window),
+			Algorithm: limit.Algorithm.String(),
+			Per:       "route",
+		}
+		if limit.PerPrincipal {
+			extensions[i].Per = "principal"
+		}
+	}
+	return extensions
+}
+
+// tokenBucketResult reports on a token bucket left with tokens.
+func tokenBucketResult(limit RateLimit, allowed bool, tokens float64) RateLimitResult {
+	perToken := float64(limit.Window) / float64(limit.Requests)
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(limit.Requests) - tokens) * perToken),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+	return result
+}
+
+// slidingWindowResult reports on a sliding window elapsed into its current
+// fixed window, counting current requests there and previous in the one
+// before.
+func slidingWindowResult(limit RateLimit, allowed bool, current, previous int, elapsed time.Duration) RateLimitResult {
+	weight := 1 - float64(elapsed)/float64(limit.Window)
+	used := float64(previous)*weight + float64(current)
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Remaining: max(0, limit.Requests-int(math.Ceil(used))),
+		Reset:     limit.Window - elapsed,
+	}
+	if !allowed {
+		if spare := limit.Requests - 1 - current; spare >= 0 && previous > 0 {
+			// The previous window's weight has to drop enough.
+			result.RetryAfter = time.Duration((1-float64(spare)/float64(previous))*float64(limit.Window)) - elapsed
+		} else {
+			// The current window is spent: its requests weigh on the next.
+			next := 1 - float64(limit.Requests-1)/float64(current)
+			result.RetryAfter = limit.Window - elapsed + time.Duration(next*float64(limit.Window))
+		}
+	}
+	return result
+}
+
+// MemoryRateLimitStore keeps rate limit budgets in memory, for a single
+// instance of a service.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+type rateBucket struct {
+	// tokens and updated are the state of a token bucket; start, current
+	// and previous that of a sliding window.
+	tokens   float64
+	updated  time.Time
+	start    time.Time
+	current  int
+	previous int
+	// expires is when the bucket is back to its initial state.
+	expires time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*rateBucket), swept: time.Now(), now: time.Now}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.Sub(s.swept) > time.Minute {
+		for key, bucket := range s.buckets {
+			if now.After(bucket.expires) {
+				delete(s.buckets, key)
+			}
+		}
+		s.swept = now
+	}
+	bucket := s.buckets[key]
+	if bucket == nil {
+		bucket = &rateBucket{tokens: float64(limit.Requests), updated: now, start: now.Truncate(limit.Window)}
+		s.buckets[key] = bucket
+	}
+
+	if limit.Algorithm == SlidingWindow {
+		start := now.Truncate(limit.Window)
+		if !bucket.start.Equal(start) {
+			bucket.previous = 0
+			if bucket.start.Equal(start.Add(-limit.Window)) {
+				bucket.previous = bucket.current
+			}
+			bucket.current, bucket.start = 0, start
+		}
+		elapsed := now.Sub(start)
+		weight := 1 - float64(elapsed)/float64(limit.Window)
+		allowed := float64(bucket.previous)*weight+float64(bucket.current+1) <= float64(limit.Requests)
+		if allowed {
+			bucket.current++
+		}
+		bucket.expires = start.Add(2 * limit.Window)
+		return slidingWindowResult(limit, allowed, bucket.current, bucket.previous, elapsed), nil
+	}
+
+	refill := now.Sub(bucket.updated).Seconds() * float64(limit.Requests) / limit.Window.Seconds()
+	bucket.tokens = math.Min(float64(limit.Requests), bucket.tokens+refill)
+	bucket.updated = now
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+	result := tokenBucketResult(limit, allowed, bucket.tokens)
+	bucket.expires = now.Add(result.Reset)
+	return result, nil
+}
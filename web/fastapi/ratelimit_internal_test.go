@@ -0,0 +1,98 @@
+package fastapi
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is the time of a MemoryRateLimitStore under test.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func near(got, want time.Duration) bool {
+	return got > want-time.Millisecond && got < want+time.Millisecond
+}
+
+func TestTokenBucket(t *testing.T) {
+	limit := RateLimit{Requests: 4, Window: 4 * time.Second}
+	at := &clock{time.Unix(1000, 0)}
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return at.now }
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		allowed bool
+		// remaining, reset and retryAfter are those of the result.
+		remaining  int
+		reset      time.Duration
+		retryAfter time.Duration
+	}{
+		{"full bucket", 0, true, 3, time.Second, 0},
+		{"burst", 0, true, 2, 2 * time.Second, 0},
+		{"burst", 0, true, 1, 3 * time.Second, 0},
+		{"last token", 0, true, 0, 4 * time.Second, 0},
+		{"empty bucket", 0, false, 0, 4 * time.Second, time.Second},
+		{"half a token", 500 * time.Millisecond, false, 0, 3500 * time.Millisecond, 500 * time.Millisecond},
+		{"refilled token", 500 * time.Millisecond, true, 0, 4 * time.Second, 0},
+		{"refill is capped", time.Hour, true, 3, time.Second, 0},
+	}
+	for _, test := range tests {
+		at.advance(test.advance)
+		result, err := store.Take(context.Background(), "key", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != test.allowed || result.Remaining != test.remaining || !near(result.Reset, test.reset) || !near(result.RetryAfter, test.retryAfter) {
+			t.Errorf("%s: got %+v, want allowed %v, remaining %d, reset %v, retry after %v",
+				test.name, result, test.allowed, test.remaining, test.reset, test.retryAfter)
+		}
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	limit := RateLimit{Requests: 4, Window: 10 * time.Second, Algorithm: SlidingWindow}
+	// 2s into a fixed window.
+	at := &clock{time.Unix(1002, 0)}
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return at.now }
+
+	tests := []struct {
+		name       string
+		advance    time.Duration
+		allowed    bool
+		remaining  int
+		reset      time.Duration
+		retryAfter time.Duration
+	}{
+		{"empty window", 0, true, 3, 8 * time.Second, 0},
+		{"requests", 0, true, 2, 8 * time.Second, 0},
+		{"requests", 0, true, 1, 8 * time.Second, 0},
+		{"last request", 0, true, 0, 8 * time.Second, 0},
+		// The 4 requests weigh on the next window until 2.5s into it.
+		{"spent window", 0, false, 0, 8 * time.Second, 10500 * time.Millisecond},
+		// Halfway through the next window, they count as 2.
+		{"weighted previous window", 13 * time.Second, true, 1, 5 * time.Second, 0},
+		{"weighted previous window", 0, true, 0, 5 * time.Second, 0},
+		{"previous window too heavy", 0, false, 0, 5 * time.Second, 2500 * time.Millisecond},
+		{"previous window light enough", 2500 * time.Millisecond, true, 0, 2500 * time.Millisecond, 0},
+		{"windows elapsed", 20 * time.Second, true, 3, 2500 * time.Millisecond, 0},
+	}
+	for _, test := range tests {
+		at.advance(test.advance)
+		result, err := store.Take(context.Background(), "key", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != test.allowed || result.Remaining != test.remaining || !near(result.Reset, test.reset) || !near(result.RetryAfter, test.retryAfter) {
+			t.Errorf("%s: got %+v, want allowed %v, remaining %d, reset %v, retry after %v",
+				test.name, result, test.allowed, test.remaining, test.reset, test.retryAfter)
+		}
+	}
+}
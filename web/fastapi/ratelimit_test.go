@@ -0,0 +1,126 @@
+package fastapi_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"web/fastapi"
+)
+
+func limitedRouter(limits ...fastapi.RateLimit) *fastapi.Router {
+	r := fastapi.NewRouter()
+	for _, path := range []string{"/a", "/b"} {
+		r.Handle(http.MethodGet, path, func(c *gin.Context, in struct{}) (struct{}, error) {
+			return struct{}{}, nil
+		}, fastapi.RateLimited(limits...))
+	}
+	r.Group("secured").Handle(http.MethodGet, "/a", func(c *gin.Context, in struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	}, fastapi.RateLimited(limits...), fastapi.Secured(fastapi.Require(fastapi.APIKeyAuth("key", "header", "X-API-Key", func(c *gin.Context, key string) (*fastapi.Principal, error) {
+		return &fastapi.Principal{Subject: key}, nil
+	}))))
+	return r
+}
+
+// serveFrom sends a request from the client at remoteAddr.
+func serveFrom(handler gin.HandlerFunc, target, remoteAddr string, header http.Header) *httptest.ResponseRecorder {
+	engine := gin.New()
+	engine.Any("/*path", handler)
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.RemoteAddr = remoteAddr
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRateLimited(t *testing.T) {
+	r := limitedRouter(fastapi.RateLimit{Requests: 2, Window: time.Minute})
+	for remaining, want := range []string{"1", "0"} {
+		recorder := serve(r.GinHandler, http.MethodGet, "/a", "", nil)
+		expectStatus(t, recorder, http.StatusOK)
+		if got := recorder.Header().Get("RateLimit-Remaining"); got != want {
+			t.Errorf("request %d: RateLimit-Remaining %s, want %s", remaining, got, want)
+		}
+	}
+	recorder := serve(r.GinHandler, http.MethodGet, "/a", "", nil)
+	expectStatus(t, recorder, http.StatusTooManyRequests)
+	header := recorder.Header()
+	if header.Get("Retry-After") != "30" || header.Get("RateLimit-Limit") != "2" || header.Get("RateLimit-Policy") != "2;w=60" {
+		t.Errorf("headers %v, want Retry-After 30 and the 2;w=60 policy", header)
+	}
+	// Budgets are per route unless named.
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/b", "", nil), http.StatusOK)
+}
+
+func TestRateLimitedByName(t *testing.T) {
+	r := limitedRouter(fastapi.RateLimit{Requests: 1, Window: time.Minute, Name: "quota"})
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/a", "", nil), http.StatusOK)
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/b", "", nil), http.StatusTooManyRequests)
+}
+
+func TestRateLimitedPerPrincipal(t *testing.T) {
+	type client struct {
+		target     string
+		remoteAddr string
+		header     http.Header
+	}
+	tests := []struct {
+		name          string
+		first, second client
+		// want is the status of the second request.
+		want int
+	}{
+		{
+			name:   "same principal",
+			first:  client{"/secured/a", "192.0.2.1:1", http.Header{"X-Api-Key": {"alice"}}},
+			second: client{"/secured/a", "192.0.2.2:1", http.Header{"X-Api-Key": {"alice"}}},
+			want:   http.StatusTooManyRequests,
+		},
+		{
+			name:   "other principal",
+			first:  client{"/secured/a", "192.0.2.1:1", http.Header{"X-Api-Key": {"alice"}}},
+			second: client{"/secured/a", "192.0.2.1:1", http.Header{"X-Api-Key": {"bob"}}},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "same address",
+			first:  client{"/a", "192.0.2.1:1", nil},
+			second: client{"/a", "192.0.2.1:2", nil},
+			want:   http.StatusTooManyRequests,
+		},
+		{
+			name:   "other address",
+			first:  client{"/a", "192.0.2.1:1", nil},
+			second: client{"/a", "192.0.2.2:1", nil},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "spoofed forwarded address",
+			first:  client{"/a", "192.0.2.1:1", http.Header{"X-Forwarded-For": {"198.51.100.1"}}},
+			second: client{"/a", "192.0.2.1:1", http.Header{"X-Forwarded-For": {"198.51.100.2"}}},
+			want:   http.StatusTooManyRequests,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := limitedRouter(fastapi.RateLimit{Requests: 1, Window: time.Minute, PerPrincipal: true})
+			expectStatus(t, serveFrom(r.GinHandler, test.first.target, test.first.remoteAddr, test.first.header), http.StatusOK)
+			expectStatus(t, serveFrom(r.GinHandler, test.second.target, test.second.remoteAddr, test.second.header), test.want)
+		})
+	}
+}
+
+func TestUseClientIP(t *testing.T) {
+	r := limitedRouter(fastapi.RateLimit{Requests: 1, Window: time.Minute, PerPrincipal: true})
+	r.UseClientIP(func(c *gin.Context) string {
+		return c.GetHeader("X-Real-IP")
+	})
+	expectStatus(t, serveFrom(r.GinHandler, "/a", "192.0.2.1:1", http.Header{"X-Real-Ip": {"198.51.100.1"}}), http.StatusOK)
+	expectStatus(t, serveFrom(r.GinHandler, "/a", "192.0.2.1:1", http.Header{"X-Real-Ip": {"198.51.100.2"}}), http.StatusOK)
+	expectStatus(t, serveFrom(r.GinHandler, "/a", "192.0.2.2:1", http.Header{"X-Real-Ip": {"198.51.100.1"}}), http.StatusTooManyRequests)
+}
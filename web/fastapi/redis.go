@@ -0,0 +1,183 @@
+package fastapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// The scripts read the clock of the Redis server, so that the instances of a
+// service agree on it.
+var (
+	tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now = clock[1] * 1000 + math.floor(clock[2] / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or capacity
+local updated = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + (now - updated) * capacity / window)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', now)
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, tostring(tokens)}
+`)
+	slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now = clock[1] * 1000 + math.floor(clock[2] / 1000)
+local start = now - now % window
+local state = redis.call('HMGET', KEYS[1], 'start', 'current', 'previous')
+local current = tonumber(state[2]) or 0
+local previous = tonumber(state[3]) or 0
+local last = tonumber(state[1]) or start
+if last ~= start then
+	previous = 0
+	if last == start - window then
+		previous = current
+	end
+	current = 0
+end
+local allowed = 0
+if previous * (1 - (now - start) / window) + current + 1 <= limit then
+	current = current + 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'start', start, 'current', current, 'previous', previous)
+redis.call('PEXPIRE', KEYS[1], 2 * window)
+return {allowed, current, previous, now - start}
+`)
+)
+
+// RedisRateLimitStore keeps rate limit budgets in Redis, shared by every
+// instance of a service. It needs Redis 5 or later.
+type RedisRateLimitStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisRateLimitStore returns a store keeping budgets under keys starting
+// with prefix, e.g. "myservice:".
+func NewRedisRateLimitStore(client redis.Scripter, prefix string) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client, prefix: prefix}
+}
+
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	keys := []string{s.prefix + "ratelimit:" + key}
+	window := max(1, limit.Window.Milliseconds())
+	if limit.Algorithm == SlidingWindow {
+		state, err := slidingWindowScript.Run(ctx, s.client, keys, limit.Requests, window).Int64Slice()
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+		return slidingWindowResult(limit, state[0] == 1, int(state[1]), int(state[2]), time.Duration(state[3])*time.Millisecond), nil
+	}
+
+	state, err := tokenBucketScript.Run(ctx, s.client, keys, limit.Requests, window).Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	allowed, _ := state[0].(int64)
+	tokens, _ := state[1].(string)
+	remaining, err := strconv.ParseFloat(tokens, 64)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return tokenBucketResult(limit, allowed == 1, remaining), nil
+}
+
+// RedisIdempotencyStore keeps idempotency records in Redis, shared by every
+// instance of a service.
+type RedisIdempotencyStore struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisIdempotencyStore returns a store keeping records under keys
+// starting with prefix, e.g. "myservice:".
+func NewRedisIdempotencyStore(client redis.Cmdable, prefix string) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client, prefix: prefix}
+}
+
+func (s *RedisIdempotencyStore) Claim(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	key = s.prefix + "idempotency:" + key
+	for {
+		claimed, err := s.client.SetNX(ctx, key, data, ttl).Result()
+		if err != nil || claimed {
+			return nil, err
+		}
+		stored, err := s.client.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			// The record expired or was released in between.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		existing := &IdempotencyRecord{}
+		if err := json.Unmarshal(stored, existing); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+}
+
+func (s *RedisIdempotencyStore) Save(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+"idempotency:"+key, data, ttl).Err()
+}
+
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+"idempotency:"+key).Err()
+}
+
+// RedisResponseCache keeps responses in Redis, shared by every instance of a
+// service.
+type RedisResponseCache struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisResponseCache returns a cache keeping responses under keys
+// starting with prefix, e.g. "myservice:".
+func NewRedisResponseCache(client redis.Cmdable, prefix string) *RedisResponseCache {
+	return &RedisResponseCache{client: client, prefix: prefix}
+}
+
+func (s *RedisResponseCache) Get(ctx context.Context, key string) (*CachedResponse, error) {
+	data, err := s.client.Get(ctx, s.prefix+"cache:"+key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	response := &CachedResponse{}
+	if err := json.Unmarshal(data, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (s *RedisResponseCache) Set(ctx context.Context, key string, response CachedResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+"cache:"+key, data, ttl).Err()
+}
@@ -0,0 +1,389 @@
+package fastapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	openapi "github.com/go-openapi/spec"
+	"net/http"
+	"reflect"
+)
+
+// Media types responses can be rendered as, in order of preference. Each
+// one is also accepted under its aliases.
+var responseMediaTypes = []string{binding.MIMEJSON, binding.MIMEXML, binding.MIMEYAML2, binding.MIMEMSGPACK2}
+
+var mediaTypeAliases = map[string][]string{
+	binding.MIMEXML:      {binding.MIMEXML2},
+	binding.MIMEYAML2:    {binding.MIMEYAML},
+	binding.MIMEMSGPACK2: {binding.MIMEMSGPACK},
+}
+
+// Response lets a handler choose the status and headers of its response.
+// A zero Status falls back to the route's status. Body is written like any
+// other output.
+type Response[T any] struct {
+	Status int
+	Header http.Header
+	Body   T
+}
+
+func (r Response[T]) responseParts() (int, http.Header, interface{}) {
+	return r.Status, r.Header, r.Body
+}
+
+func (Response[T]) bodyType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// NoContent is the output of handlers answering without a body, with 204 No
+// Content unless the route sets another status.
+type NoContent struct{}
+
+func (NoContent) responseParts() (int, http.Header, interface{}) {
+	return 0, nil, NoContent{}
+}
+
+func (NoContent) bodyType() reflect.Type {
+	return nil
+}
+
+// rawBody is implemented by outputs writing the response themselves, like
+// Stream, instead of being encoded.
+type rawBody interface {
+	write(c *gin.Context, status int) error
+}
+
+var rawBodyType = reflect.TypeOf((*rawBody)(nil)).Elem()
+
+type responder interface {
+	responseParts() (int, http.Header, interface{})
+	bodyType() reflect.Type
+}
+
+var noContentType = reflect.TypeOf(NoContent{})
+
+// responseBodyType returns the type written as the body for an output type,
+// or nil when there is none.
+func responseBodyType(outputType reflect.Type) reflect.Type {
+	if outputType == nil || outputType == noContentType {
+		return nil
+	}
+	if outputType.Implements(reflect.TypeOf((*responder)(nil)).Elem()) {
+		return responseBodyType(reflect.Zero(outputType).Interface().(responder).bodyType())
+	}
+	return outputType
+}
+
+// responseSpec is a response documented with Returns.
+type responseSpec struct {
+	status      int
+	bodyType    reflect.Type
+	description string
+}
+
+// Status sets the status of successful responses, 200 by default.
+func Status(status int) RouteOption {
+	return func(rt *route) {
+		rt.status = status
+	}
+}
+
+// Returns documents another response the handler may give through
+// Response, with a body of the type of body, or none when body is nil.
+func Returns(status int, body interface{}, description string) RouteOption {
+	return func(rt *route) {
+		rt.responses = append(rt.responses, responseSpec{
+			status:      status,
+			bodyType:    responseBodyType(reflect.TypeOf(body)),
+			description: description,
+		})
+	}
+}
+
+// Produces sets the media types of the route's responses. Encoded outputs
+// are negotiated between JSON, XML, YAML and MessagePack by default; all but
+// XML, which follows encoding/xml rules, use the json field names. XML is
+// left out for bodies encoding/xml cannot encode, such as maps. Streams may
+// declare any media type and default to application/octet-stream.
+func Produces(mediaTypes ...string) RouteOption {
+	return func(rt *route) {
+		rt.produces = append([]string{}, mediaTypes...)
+	}
+}
+
+func checkProduces(rt *route) {
+	if !rt.negotiated() {
+		return
+	}
+	if len(rt.produces) == 0 {
+		for _, mediaType := range responseMediaTypes {
+			if mediaType != binding.MIMEXML || rt.xmlEncodable() {
+				rt.produces = append(rt.produces, mediaType)
+			}
+		}
+		return
+	}
+	for i, mediaType := range rt.produces {
+		canonical, ok := canonicalMediaType(mediaType)
+		if !ok {
+			panic("Unsupported response media type " + mediaType)
+		}
+		if canonical == binding.MIMEXML && !rt.xmlEncodable() {
+			panic("Response media type " + mediaType + " cannot encode the route's bodies")
+		}
+		rt.produces[i] = canonical
+	}
+}
+
+var xmlMarshalerType = reflect.TypeOf((*xml.Marshaler)(nil)).Elem()
+
+// xmlEncodable reports whether encoding/xml can encode the route's bodies.
+func (rt *route) xmlEncodable() bool {
+	bodyTypes := []reflect.Type{responseBodyType(rt.outputType)}
+	for _, spec := range rt.responses {
+		bodyTypes = append(bodyTypes, spec.bodyType)
+	}
+	for _, bodyType := range bodyTypes {
+		if bodyType != nil && !bodyType.Implements(rawBodyType) && !xmlEncodable(bodyType, make(map[reflect.Type]bool)) {
+			return false
+		}
+	}
+	return true
+}
+
+// xmlEncodable reports whether encoding/xml can encode values of goType,
+// which must hold no maps, channels or functions. The values of interfaces
+// are only known once encoded.
+func xmlEncodable(goType reflect.Type, visited map[reflect.Type]bool) bool {
+	for goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+	for _, marshaler := range []reflect.Type{xmlMarshalerType, textMarshalerType} {
+		if goType.Implements(marshaler) || reflect.PtrTo(goType).Implements(marshaler) {
+			return true
+		}
+	}
+	switch goType.Kind() {
+	case reflect.Map, reflect.Chan, reflect.Func:
+		return false
+	case reflect.Slice, reflect.Array:
+		return xmlEncodable(goType.Elem(), visited)
+	case reflect.Struct:
+		if visited[goType] {
+			return true
+		}
+		visited[goType] = true
+		for i := 0; i < goType.NumField(); i++ {
+			field := goType.Field(i)
+			if (field.IsExported() || field.Anonymous) && field.Tag.Get("xml") != "-" && !xmlEncodable(field.Type, visited) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func canonicalMediaType(mediaType string) (string, bool) {
+	for _, canonical := range responseMediaTypes {
+		if mediaType == canonical {
+			return canonical, true
+		}
+		for _, alias := range mediaTypeAliases[canonical] {
+			if mediaType == alias {
+				return canonical, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (rt *route) successStatus() int {
+	switch {
+	case rt.status != 0:
+		return rt.status
+	case rt.outputType == noContentType:
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
+// negotiated reports whether the route may write an encoded body, whose
+// media type is negotiated.
+func (rt *route) negotiated() bool {
+	bodyTypes := []reflect.Type{responseBodyType(rt.outputType)}
+	for _, spec := range rt.responses {
+		bodyTypes = append(bodyTypes, spec.bodyType)
+	}
+	for _, bodyType := range bodyTypes {
+		if bodyType != nil && !bodyType.Implements(rawBodyType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rt *route) mediaTypes() []string {
+	switch {
+	case len(rt.produces) > 0:
+		return rt.produces
+	case rt.negotiated():
+		return responseMediaTypes
+	case responseBodyType(rt.outputType) == streamType:
+		return []string{"application/octet-stream"}
+	}
+	return nil
+}
+
+// negotiate picks the media type of the response from the Accept header,
+// before the handler runs so that unacceptable requests have no effect.
+func negotiate(c *gin.Context, rt *route) (string, error) {
+	var offered []string
+	for _, mediaType := range rt.mediaTypes() {
+		offered = append(offered, mediaType)
+		offered = append(offered, mediaTypeAliases[mediaType]...)
+	}
+	mediaType, _ := canonicalMediaType(c.NegotiateFormat(offered...))
+	if mediaType == "" {
+		return "", NewError(http.StatusNotAcceptable, "not_acceptable", "none of the accepted media types is offered").
+			WithDetails(gin.H{"offered": rt.mediaTypes()})
+	}
+	return mediaType, nil
+}
+
+// writeResponse writes a handler's output as {"response": output} in the
+// negotiated media type, with the status and headers it chose.
+func writeResponse(c *gin.Context, rt *route, mediaType string, output interface{}) error {
+	status, header, body := rt.successStatus(), http.Header(nil), output
+	if parts, ok := output.(responder); ok {
+		var chosen int
+		chosen, header, body = parts.responseParts()
+		if chosen != 0 {
+			status = chosen
+		}
+	}
+	for name, values := range header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	if rt.cacheControl != "" && status < http.StatusMultipleChoices && c.Writer.Header().Get("Cache-Control") == "" {
+		c.Header("Cache-Control", rt.cacheControl)
+	}
+
+	if raw, ok := body.(rawBody); ok {
+		return raw.write(c, status)
+	}
+	if _, empty := body.(NoContent); empty || !bodyAllowed(status) {
+		c.Status(status)
+		c.Writer.WriteHeaderNow()
+		return nil
+	}
+
+	envelope := gin.H{"response": body}
+	switch mediaType {
+	case binding.MIMEXML:
+		// Encoded before writing anything, so that failures are reported.
+		data, err := xml.Marshal(envelope)
+		if err != nil {
+			return err
+		}
+		c.Data(status, binding.MIMEXML+"; charset=utf-8", data)
+	case binding.MIMEYAML2, binding.MIMEMSGPACK2:
+		// Both are rendered from the JSON encoding so that field names
+		// follow json tags like the documented schema.
+		data, err := json.Marshal(envelope)
+		if err != nil {
+			return err
+		}
+		if mediaType == binding.MIMEYAML2 {
+			data, err = jsonToYAML(data)
+			if err != nil {
+				return err
+			}
+			c.Data(status, binding.MIMEYAML2+"; charset=utf-8", data)
+			return nil
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var generic interface{}
+		if err := decoder.Decode(&generic); err != nil {
+			return err
+		}
+		c.Render(status, render.MsgPack{Data: typedNumbers(generic)})
+	default:
+		c.JSON(status, envelope)
+	}
+	return nil
+}
+
+// typedNumbers replaces the json.Numbers of a decoded value with integers
+// where possible, so that MessagePack keeps them integral.
+func typedNumbers(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case json.Number:
+		if integer, err := typed.Int64(); err == nil {
+			return integer
+		}
+		float, _ := typed.Float64()
+		return float
+	case map[string]interface{}:
+		for key, item := range typed {
+			typed[key] = typedNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = typedNumbers(item)
+		}
+	}
+	return value
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// envelopeSchema describes the {"response": ...} object bodies are wrapped
+// in.
+func envelopeSchema(schema openapi.Schema) openapi.Schema {
+	envelope := new(openapi.Schema).Typed("object", "").SetProperty("response", schema)
+	envelope.Required = []string{"response"}
+	return *envelope
+}
+
+// addSuccessResponses documents the route's successful response and those
+// declared with Returns.
+func addSuccessResponses(op *openapi.Operation, rt *route, gen *schemaGenerator) {
+	responses := append([]responseSpec{{
+		status:   rt.successStatus(),
+		bodyType: responseBodyType(rt.outputType),
+	}}, rt.responses...)
+	for _, spec := range responses {
+		resp := openapi.NewResponse().WithDescription(spec.description)
+		if spec.description == "" {
+			resp.WithDescription(http.StatusText(spec.status))
+		}
+		switch {
+		case spec.bodyType == streamType:
+			schema := fileSchema()
+			resp.WithSchema(&schema)
+		case spec.bodyType == eventStreamType:
+			schema := gen.schemaFor(rt.events)
+			resp.WithSchema(&schema)
+			if spec.description == "" {
+				resp.WithDescription("Stream of server-sent events with JSON data")
+			}
+		case spec.bodyType != nil && bodyAllowed(spec.status):
+			schema := envelopeSchema(gen.schemaFor(spec.bodyType))
+			resp.WithSchema(&schema)
+			if spec.status == rt.successStatus() && rt.responseExample != nil {
+				resp.AddExample(binding.MIMEJSON, gin.H{"response": rt.responseExample})
+			}
+		}
+		op.Responses.StatusCodeResponses[spec.status] = *resp
+	}
+	op.Produces = append([]string{}, rt.mediaTypes()...)
+}
@@ -0,0 +1,114 @@
+package fastapi_test
+
+import (
+	"encoding/xml"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"testing"
+	"web/fastapi"
+)
+
+type greeting struct {
+	Text string `json:"text" xml:"text"`
+}
+
+type counts struct {
+	Counts map[string]int `json:"counts"`
+}
+
+type dynamic struct {
+	Value interface{} `json:"value"`
+}
+
+func negotiationRouter() *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodGet, "/greeting", func(c *gin.Context, in struct{}) (greeting, error) {
+		return greeting{Text: "hello"}, nil
+	})
+	r.Handle(http.MethodGet, "/counts", func(c *gin.Context, in struct{}) (counts, error) {
+		return counts{Counts: map[string]int{"a": 1}}, nil
+	})
+	r.Handle(http.MethodGet, "/dynamic", func(c *gin.Context, in struct{}) (dynamic, error) {
+		return dynamic{Value: map[string]int{"a": 1}}, nil
+	})
+	r.Handle(http.MethodPost, "/greeting", func(c *gin.Context, in struct{}) (fastapi.Response[greeting], error) {
+		return fastapi.Response[greeting]{
+			Status: http.StatusCreated,
+			Header: http.Header{"Location": {"/greeting"}},
+			Body:   greeting{Text: "created"},
+		}, nil
+	})
+	r.Handle(http.MethodDelete, "/greeting", func(c *gin.Context, in struct{}) (fastapi.NoContent, error) {
+		return fastapi.NoContent{}, nil
+	})
+	return r
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"json by default", http.MethodGet, "/greeting", "", http.StatusOK, "application/json", `{"response":{"text":"hello"}}`},
+		{"json", http.MethodGet, "/greeting", "application/json", http.StatusOK, "application/json", `"hello"`},
+		{"xml", http.MethodGet, "/greeting", "application/xml", http.StatusOK, "application/xml", "<text>hello</text>"},
+		{"xml alias", http.MethodGet, "/greeting", "text/xml", http.StatusOK, "application/xml", "<text>hello</text>"},
+		{"yaml", http.MethodGet, "/greeting", "application/yaml", http.StatusOK, "application/yaml", "text: hello"},
+		{"not acceptable", http.MethodGet, "/greeting", "text/html", http.StatusNotAcceptable, "application/problem+json", "not_acceptable"},
+		{"maps are not offered as xml", http.MethodGet, "/counts", "application/xml", http.StatusNotAcceptable, "application/problem+json", "not_acceptable"},
+		{"maps fall back to json", http.MethodGet, "/counts", "application/xml, application/json;q=0.1", http.StatusOK, "application/json", `"a":1`},
+		{"xml failures are reported", http.MethodGet, "/dynamic", "application/xml", http.StatusInternalServerError, "application/problem+json", "internal_error"},
+		{"status and headers", http.MethodPost, "/greeting", "", http.StatusCreated, "application/json", `"created"`},
+		{"no content", http.MethodDelete, "/greeting", "text/html", http.StatusNoContent, "", ""},
+	}
+	r := negotiationRouter()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(r.GinHandler, test.method, test.target, "", http.Header{"Accept": {test.accept}})
+			expectStatus(t, recorder, test.status)
+			if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, test.contentType) {
+				t.Errorf("Content-Type %q, want %q", contentType, test.contentType)
+			}
+			if !strings.Contains(recorder.Body.String(), test.body) {
+				t.Errorf("body %q does not contain %q", recorder.Body.String(), test.body)
+			}
+		})
+	}
+}
+
+func TestNegotiateXMLEnvelope(t *testing.T) {
+	recorder := serve(negotiationRouter().GinHandler, http.MethodGet, "/greeting", "", http.Header{"Accept": {"application/xml"}})
+	var envelope struct {
+		Response greeting `xml:"response"`
+	}
+	if err := xml.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil || envelope.Response.Text != "hello" {
+		t.Errorf("decoded %+v from %q: %v", envelope, recorder.Body.String(), err)
+	}
+}
+
+func TestNegotiateSpec(t *testing.T) {
+	paths := negotiationRouter().EmitOpenAPIDefinition().Paths.Paths
+	if produces := strings.Join(paths["/counts"].Get.Produces, ","); strings.Contains(produces, "xml") {
+		t.Errorf("/counts produces %s, want no XML", produces)
+	}
+	if produces := strings.Join(paths["/greeting"].Get.Produces, ","); !strings.Contains(produces, "application/xml") {
+		t.Errorf("/greeting produces %s, want XML", produces)
+	}
+}
+
+func TestProducesXMLForMapsPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registered without panicking")
+		}
+	}()
+	fastapi.NewRouter().Handle(http.MethodGet, "/counts", func(c *gin.Context, in struct{}) (counts, error) {
+		return counts{}, nil
+	}, fastapi.Produces("application/xml"))
+}
@@ -0,0 +1,115 @@
+package fastapi
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+type route struct {
+	method     string
+	path       string
+	inputType  reflect.Type
+	outputType reflect.Type
+	// invoke calls the handler with a pointer to the bound input.
+	invoke       func(c *gin.Context, inputPtr interface{}) (interface{}, error)
+	errors       []HTTPError
+	middlewares  []Middleware
+	dependencies []func(*gin.Context) error
+	// security overrides the router's requirements when not nil.
+	security []SecurityRequirement
+	// group is the router the route was registered on.
+	group *Router
+
+	summary         string
+	description     string
+	operationID     string
+	tags            []string
+	deprecated      bool
+	deprecatedSince time.Time
+	deprecationLink string
+	sunset          time.Time
+	requestExample  interface{}
+	responseExample interface{}
+
+	status    int
+	responses []responseSpec
+	produces  []string
+
+	// events is the data type of a server-sent events route, inbound and
+	// outbound the message types of a WebSocket route.
+	events    reflect.Type
+	inbound   reflect.Type
+	outbound  reflect.Type
+	heartbeat time.Duration
+	// checkOrigin admits the origins of WebSocket upgrades, nil meaning the
+	// request's own host.
+	checkOrigin func(r *http.Request) bool
+	rateLimits  []RateLimit
+	timeout     time.Duration
+	maxBodySize int64
+	// idempotencyTTL is how long the responses of an Idempotent route are
+	// kept, zero for other routes.
+	idempotencyTTL time.Duration
+	etag           bool
+	cacheControl   string
+	cacheTTL       time.Duration
+}
+
+type RouteOption func(*route)
+
+// Errors documents the errors a route may return. Each one is listed under
+// its status in the generated spec.
+func Errors(errs ...HTTPError) RouteOption {
+	return func(rt *route) {
+		rt.errors = append(rt.errors, errs...)
+	}
+}
+
+func Summary(summary string) RouteOption {
+	return func(rt *route) {
+		rt.summary = summary
+	}
+}
+
+func Description(description string) RouteOption {
+	return func(rt *route) {
+		rt.description = description
+	}
+}
+
+// OperationID names the operation in the spec and generated clients. By
+// default it is derived from the method and path, e.g. getUsersById.
+func OperationID(id string) RouteOption {
+	return func(rt *route) {
+		rt.operationID = id
+	}
+}
+
+// Tags adds tags to the route after those of its groups.
+func Tags(tags ...string) RouteOption {
+	return func(rt *route) {
+		rt.tags = append(rt.tags, tags...)
+	}
+}
+
+func Deprecated() RouteOption {
+	return func(rt *route) {
+		rt.deprecated = true
+	}
+}
+
+// RequestExample documents an example request body.
+func RequestExample(example interface{}) RouteOption {
+	return func(rt *route) {
+		rt.requestExample = example
+	}
+}
+
+// ResponseExample documents an example of the handler's output.
+func ResponseExample(example interface{}) RouteOption {
+	return func(rt *route) {
+		rt.responseExample = example
+	}
+}
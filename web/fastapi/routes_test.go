@@ -0,0 +1,138 @@
+package fastapi_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"testing"
+	"web/fastapi"
+)
+
+type item struct {
+	ID     string `json:"id"`
+	Method string `json:"method"`
+}
+
+type itemInput struct {
+	ID string `path:"id"`
+}
+
+func methodsRouter() *fastapi.Router {
+	r := fastapi.NewRouter()
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		method := method
+		r.Handle(method, "/items/{id}", func(c *gin.Context, in itemInput) (item, error) {
+			return item{ID: in.ID, Method: method}, nil
+		})
+	}
+	r.Handle(http.MethodGet, "/items/new", func(c *gin.Context, in struct{}) (item, error) {
+		return item{ID: "new", Method: http.MethodGet}, nil
+	})
+	r.AddCall("/items", func(c *gin.Context, in struct{}) (item, error) {
+		return item{Method: http.MethodPost}, nil
+	})
+	return r
+}
+
+func TestMethods(t *testing.T) {
+	tests := []struct {
+		method string
+		target string
+		want   item
+	}{
+		{http.MethodGet, "/items/7", item{ID: "7", Method: http.MethodGet}},
+		{http.MethodPut, "/items/7", item{ID: "7", Method: http.MethodPut}},
+		{http.MethodPatch, "/items/7", item{ID: "7", Method: http.MethodPatch}},
+		{http.MethodDelete, "/items/7", item{ID: "7", Method: http.MethodDelete}},
+		{http.MethodGet, "/items/new", item{ID: "new", Method: http.MethodGet}},
+		{http.MethodPost, "/items", item{Method: http.MethodPost}},
+	}
+	r := methodsRouter()
+	for _, test := range tests {
+		t.Run(test.method+" "+test.target, func(t *testing.T) {
+			recorder := serve(r.GinHandler, test.method, test.target, "", nil)
+			expectStatus(t, recorder, http.StatusOK)
+			var got item
+			decode(t, recorder, &got)
+			if got != test.want {
+				t.Errorf("served %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	tests := []struct {
+		method string
+		target string
+		allow  string
+	}{
+		{http.MethodPost, "/items/7", "DELETE, GET, PATCH, PUT"},
+		{http.MethodDelete, "/items/new", "GET"},
+		{http.MethodGet, "/items", "POST"},
+	}
+	r := methodsRouter()
+	for _, test := range tests {
+		t.Run(test.method+" "+test.target, func(t *testing.T) {
+			recorder := serve(r.GinHandler, test.method, test.target, "", nil)
+			expectStatus(t, recorder, http.StatusMethodNotAllowed)
+			if allow := recorder.Header().Get("Allow"); allow != test.allow {
+				t.Errorf("Allow %q, want %q", allow, test.allow)
+			}
+			if code := problem(t, recorder).Code; code != "method_not_allowed" {
+				t.Errorf("code %q, want method_not_allowed", code)
+			}
+		})
+	}
+
+	recorder := serve(r.GinHandler, http.MethodGet, "/orders", "", nil)
+	expectStatus(t, recorder, http.StatusNotFound)
+	if allow := recorder.Header().Get("Allow"); allow != "" {
+		t.Errorf("Allow %q on a 404", allow)
+	}
+}
+
+func TestMethodsSpec(t *testing.T) {
+	spec := methodsRouter().EmitOpenAPIDefinition()
+	byID := spec.Paths.Paths["/items/{id}"]
+	if byID.Get == nil || byID.Put == nil || byID.Patch == nil || byID.Delete == nil {
+		t.Errorf("/items/{id} %+v, want GET, PUT, PATCH and DELETE", byID.PathItemProps)
+	}
+	if byID.Post != nil || byID.Head != nil || byID.Options != nil {
+		t.Errorf("/items/{id} has operations it does not route")
+	}
+	items := spec.Paths.Paths["/items"]
+	if items.Post == nil || items.Get != nil {
+		t.Errorf("/items %+v, want POST only", items.PathItemProps)
+	}
+}
+
+func TestHandlePanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(r *fastapi.Router)
+	}{
+		{"unsupported method", func(r *fastapi.Router) {
+			r.Handle("TRACE", "/a", func(c *gin.Context, in struct{}) (item, error) { return item{}, nil })
+		}},
+		{"duplicate route", func(r *fastapi.Router) {
+			r.Handle(http.MethodGet, "/a/{id}", func(c *gin.Context, in itemInput) (item, error) { return item{}, nil })
+			r.Handle("get", "/a/{key}", func(c *gin.Context, in struct{}) (item, error) { return item{}, nil })
+		}},
+		{"undeclared path parameter", func(r *fastapi.Router) {
+			r.Handle(http.MethodGet, "/a", func(c *gin.Context, in itemInput) (item, error) { return item{}, nil })
+		}},
+		{"input not a struct", func(r *fastapi.Router) {
+			r.Handle(http.MethodGet, "/a", func(c *gin.Context, in string) (item, error) { return item{}, nil })
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("registering did not panic")
+				}
+			}()
+			test.register(fastapi.NewRouter())
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package fastapi
+
+import (
+	openapi "github.com/go-openapi/spec"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// schemaGenerator walks Go types and collects a definition for every named
+// struct it meets. Definitions are package qualified, e.g. "main.User".
+type schemaGenerator struct {
+	// types holds the schemas registered with DescribeType.
+	types       map[reflect.Type]openapi.Schema
+	definitions openapi.Definitions
+	names       map[reflect.Type]string
+	owners      map[string]reflect.Type
+}
+
+func newSchemaGenerator(types map[reflect.Type]openapi.Schema) *schemaGenerator {
+	return &schemaGenerator{
+		types:       types,
+		definitions: make(openapi.Definitions),
+		names:       make(map[reflect.Type]string),
+		owners:      make(map[string]reflect.Type),
+	}
+}
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func sanitizeDefinitionName(name string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(name, "_"), "_")
+}
+
+// definitionName picks "pkg.Type" and falls back to the full import path
+// when two packages share the same name.
+func (g *schemaGenerator) definitionName(goType reflect.Type) string {
+	if name, present := g.names[goType]; present {
+		return name
+	}
+	pkgPath := goType.PkgPath()
+	short := pkgPath[strings.LastIndex(pkgPath, "/")+1:]
+	name := sanitizeDefinitionName(short + "." + goType.Name())
+	if owner, taken := g.owners[name]; taken && owner != goType {
+		name = sanitizeDefinitionName(strings.ReplaceAll(pkgPath, "/", ".") + "." + goType.Name())
+	}
+	g.names[goType] = name
+	g.owners[name] = goType
+	return name
+}
+
+func definitionRef(name string) string {
+	return "#/definitions/" + name
+}
+
+// schemaFor returns the schema of a value of goType, registering
+// definitions for the named structs it refers to.
+func (g *schemaGenerator) schemaFor(goType reflect.Type) openapi.Schema {
+	if goType.Kind() == reflect.Ptr {
+		return withExtension(g.schemaFor(goType.Elem()), "x-nullable", true)
+	}
+	if schema := g.knownSchema(goType); schema != nil {
+		return *schema
+	}
+
+	switch goType.Kind() {
+	case reflect.Interface:
+		return openapi.Schema{}
+	case reflect.Slice, reflect.Array:
+		items := g.schemaFor(goType.Elem())
+		return *openapi.ArrayProperty(&items)
+	case reflect.Map:
+		values := g.schemaFor(goType.Elem())
+		return *openapi.MapProperty(&values)
+	case reflect.Struct:
+		if goType.Name() == "" {
+			return g.structSchema(goType)
+		}
+		name := g.definitionName(goType)
+		if _, present := g.definitions[name]; !present {
+			// Reserve the name first so recursive types end in a reference.
+			g.definitions[name] = openapi.Schema{}
+			g.definitions[name] = g.structSchema(goType)
+		}
+		return *openapi.RefSchema(definitionRef(name))
+	}
+
+	if schema := primitiveSchema(goType.Kind()); schema != nil {
+		return *schema
+	}
+	return openapi.Schema{}
+}
+
+// structSchema describes a struct the way encoding/json serializes it.
+// Parameter fields are left out, they are documented as parameters.
+func (g *schemaGenerator) structSchema(structType reflect.Type) openapi.Schema {
+	schema := openapi.Schema{}
+	schema.Typed("object", "")
+	schema.Properties = make(map[string]openapi.Schema)
+
+	for _, field := range jsonFields(structType) {
+		fieldSchema := g.fieldSchema(field)
+		schema.Properties[field.name] = fieldSchema
+		if parseConstraints(field.Tag).required {
+			schema.Required = append(schema.Required, field.name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func (g *schemaGenerator) fieldSchema(field jsonField) openapi.Schema {
+	var schema openapi.Schema
+	if field.asString && isStringable(field.Type) {
+		schema = *openapi.StringProperty()
+		if field.Type.Kind() == reflect.Ptr {
+			schema = withExtension(schema, "x-nullable", true)
+		}
+	} else {
+		schema = g.schemaFor(field.Type)
+	}
+
+	cons := parseConstraints(field.Tag)
+	if schema.Ref.String() == "" {
+		validations := schema.Validations()
+		applyConstraints(&validations.CommonValidations, &schema.Format, field.Type, cons)
+		schema.WithValidations(validations)
+	}
+	if items := schema.Items; items != nil && items.Schema != nil && items.Schema.Ref.String() == "" {
+		itemType := field.Type
+		for itemType.Kind() == reflect.Ptr {
+			itemType = itemType.Elem()
+		}
+		itemSchema := *items.Schema
+		validations := itemSchema.Validations()
+		applyConstraints(&validations.CommonValidations, &itemSchema.Format, itemType.Elem(), cons.items())
+		itemSchema.WithValidations(validations)
+		schema.Items = &openapi.SchemaOrArray{Schema: &itemSchema}
+	}
+	if field.omitEmpty {
+		schema = withExtension(schema, "x-omitempty", true)
+	}
+	if _, nullable := schema.Extensions["x-nullable"]; nullable && cons.required {
+		// Required fields must not be null either.
+		schema = withoutExtension(schema, "x-nullable")
+	}
+	return schema
+}
+
+// withExtension sets a vendor extension on a copy of the schema, leaving any
+// extensions map it shares with other schemas untouched.
+func withExtension(schema openapi.Schema, key string, value interface{}) openapi.Schema {
+	extensions := make(openapi.Extensions, len(schema.Extensions)+1)
+	for k, v := range schema.Extensions {
+		extensions[k] = v
+	}
+	extensions.Add(key, value)
+	schema.Extensions = extensions
+	return schema
+}
+
+// withoutExtension removes a vendor extension from a copy of the schema.
+func withoutExtension(schema openapi.Schema, key string) openapi.Schema {
+	extensions := make(openapi.Extensions, len(schema.Extensions))
+	for k, v := range schema.Extensions {
+		if k != key {
+			extensions[k] = v
+		}
+	}
+	schema.Extensions = extensions
+	return schema
+}
+
+func isStringable(goType reflect.Type) bool {
+	for goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+	switch goType.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+type jsonField struct {
+	reflect.StructField
+	name      string
+	omitEmpty bool
+	asString  bool
+	depth     int
+}
+
+// jsonFields lists the serialized fields of a struct, promoting the fields
+// of untagged embedded structs. As in encoding/json, a shallower field hides
+// a deeper one with the same name.
+func jsonFields(structType reflect.Type) []jsonField {
+	var fields []jsonField
+	byName := make(map[string]int)
+
+	var walk func(structType reflect.Type, depth int, visited map[reflect.Type]bool)
+	walk = func(structType reflect.Type, depth int, visited map[reflect.Type]bool) {
+		if visited[structType] {
+			return
+		}
+		visited[structType] = true
+		defer delete(visited, structType)
+
+		for i := 0; i < structType.NumField(); i++ {
+			field := structType.Field(i)
+			if isNonBodyField(field) {
+				continue
+			}
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+				walk(fieldType, depth+1, visited)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+
+			if name == "" {
+				name = field.Name
+			}
+			jf := jsonField{StructField: field, name: name, depth: depth}
+			for _, option := range strings.Split(options, ",") {
+				switch option {
+				case "omitempty":
+					jf.omitEmpty = true
+				case "string":
+					jf.asString = true
+				}
+			}
+
+			if existing, present := byName[name]; present {
+				if fields[existing].depth > depth {
+					fields[existing] = jf
+				}
+				continue
+			}
+			byName[name] = len(fields)
+			fields = append(fields, jf)
+		}
+	}
+	walk(structType, 0, make(map[reflect.Type]bool))
+	return fields
+}
+
+func primitiveSchema(kind reflect.Kind) *openapi.Schema {
+	switch kind {
+	case reflect.Bool:
+		return openapi.BoolProperty()
+	case reflect.Int8:
+		return openapi.Int8Property()
+	case reflect.Int16:
+		return openapi.Int16Property()
+	case reflect.Int32:
+		return openapi.Int32Property()
+	case reflect.Int, reflect.Int64:
+		return openapi.Int64Property()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return openapi.Int64Property()
+	case reflect.Float32:
+		return openapi.Float32Property()
+	case reflect.Float64:
+		return openapi.Float64Property()
+	case reflect.String:
+		return openapi.StringProperty()
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package fastapi_test
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	goscanner "go/scanner"
+	"net/http"
+	"reflect"
+	"testing"
+	textscanner "text/scanner"
+	"web/fastapi"
+)
+
+type Audit struct {
+	CreatedBy string `json:"created_by"`
+	Note      string `json:"note"`
+}
+
+type Labels struct {
+	Note string `json:"note"`
+}
+
+type treeNode struct {
+	Name     string      `json:"name"`
+	Parent   *treeNode   `json:"parent"`
+	Children []*treeNode `json:"children"`
+}
+
+type record struct {
+	Audit
+	*Labels
+	Meta    Audit             `json:"meta"`
+	Note    string            `json:"note"`
+	Count   int64             `json:"count,string"`
+	Size    *int              `json:"size,omitempty"`
+	Tags    map[string]string `json:"tags"`
+	Extra   interface{}       `json:"extra"`
+	Tree    treeNode          `json:"tree"`
+	Ignored string            `json:"-"`
+	Inline  struct {
+		Depth int `json:"depth"`
+	} `json:"inline"`
+	Scanners struct {
+		Go   goscanner.Scanner   `json:"go"`
+		Text textscanner.Scanner `json:"text"`
+	} `json:"scanners"`
+	hidden string
+}
+
+func schemaDefinitions(t *testing.T) map[string]interface{} {
+	t.Helper()
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodGet, "/record", func(c *gin.Context, in struct{}) (record, error) {
+		return record{}, nil
+	})
+	data, err := json.Marshal(r.EmitOpenAPIDefinition().Definitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var definitions map[string]interface{}
+	if err := json.Unmarshal(data, &definitions); err != nil {
+		t.Fatal(err)
+	}
+	return definitions
+}
+
+func TestSchemaWalker(t *testing.T) {
+	definitions := schemaDefinitions(t)
+	props := at(definitions, "fastapi_test.record", "properties")
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"embedded fields are promoted", at(props, "created_by", "type"), "string"},
+		{"embedded pointer fields are promoted", at(props, "note", "type"), "string"},
+		{"the shallower field wins", at(props, "note", "x-nullable"), nil},
+		{"embedded structs are not properties", at(props, "Audit"), nil},
+		{"named structs are references", at(props, "meta", "$ref"), "#/definitions/fastapi_test.Audit"},
+		{"string option", at(props, "count", "type"), "string"},
+		{"omitempty", at(props, "size", "x-omitempty"), true},
+		{"pointers are nullable", at(props, "size", "x-nullable"), true},
+		{"pointer element", at(props, "size", "type"), "integer"},
+		{"maps", at(props, "tags", "additionalProperties", "type"), "string"},
+		{"interfaces accept anything", at(props, "extra"), map[string]interface{}{}},
+		{"skipped field", at(props, "Ignored"), nil},
+		{"unexported field", at(props, "hidden"), nil},
+		{"anonymous structs are inline", at(props, "inline", "properties", "depth", "type"), "integer"},
+		{"recursive pointer", at(definitions, "fastapi_test.treeNode", "properties", "parent", "$ref"), "#/definitions/fastapi_test.treeNode"},
+		{"recursive slice", at(definitions, "fastapi_test.treeNode", "properties", "children", "items", "$ref"), "#/definitions/fastapi_test.treeNode"},
+		{"package qualified", at(props, "scanners", "properties", "go", "$ref"), "#/definitions/scanner.Scanner"},
+		{"full path on collision", at(props, "scanners", "properties", "text", "$ref"), "#/definitions/text.scanner.Scanner"},
+		{"promoted fields of other packages", at(definitions, "text.scanner.Scanner", "properties", "Line", "type"), "integer"},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("%s: %v, want %v", test.name, test.got, test.want)
+		}
+	}
+
+	for _, name := range []string{"fastapi_test.record", "fastapi_test.Audit", "fastapi_test.treeNode", "scanner.Scanner", "text.scanner.Scanner"} {
+		if _, present := definitions[name]; !present {
+			t.Errorf("%s is not defined", name)
+		}
+	}
+	if _, present := definitions["fastapi_test.Labels"]; present {
+		t.Error("fastapi_test.Labels is defined though only embedded")
+	}
+}
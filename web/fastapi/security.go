@@ -0,0 +1,352 @@
+package fastapi
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/gin-gonic/gin"
+	openapi "github.com/go-openapi/spec"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const principalContextKey = "fastapi.principal"
+
+var (
+	ErrUnauthorized      = NewError(http.StatusUnauthorized, "unauthorized", "authentication required")
+	ErrInvalidCredential = NewError(http.StatusUnauthorized, "invalid_credentials", "invalid credentials")
+	ErrInsufficientScope = NewError(http.StatusForbidden, "insufficient_scope", "insufficient scope")
+)
+
+// errNoCredentials is returned by a scheme when the request carries none of
+// its credentials, so the next alternative can be tried.
+var errNoCredentials = errors.New("no credentials")
+
+// Principal is the caller authenticated by a security scheme. Handlers get
+// it from PrincipalFrom or from an input field `depends:""` of type
+// *Principal.
+type Principal struct {
+	Subject string
+	Scheme  string
+	Scopes  []string
+	Claims  map[string]interface{}
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	for _, granted := range p.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func PrincipalFrom(c *gin.Context) *Principal {
+	value, _ := c.Get(principalContextKey)
+	principal, _ := value.(*Principal)
+	return principal
+}
+
+// SecurityScheme authenticates requests and describes itself in both
+// document versions. SwaggerDefinition may return nil for schemes Swagger
+// 2.0 cannot express.
+type SecurityScheme interface {
+	Name() string
+	Authenticate(c *gin.Context) (*Principal, error)
+	Challenge() string
+	SwaggerDefinition() *openapi.SecurityScheme
+	OpenAPIDefinition() OpenAPISecurityScheme
+}
+
+type OpenAPISecurityScheme struct {
+	Type         string             `json:"type"`
+	Description  string             `json:"description,omitempty"`
+	Name         string             `json:"name,omitempty"`
+	In           string             `json:"in,omitempty"`
+	Scheme       string             `json:"scheme,omitempty"`
+	BearerFormat string             `json:"bearerFormat,omitempty"`
+	Flows        *OpenAPIOAuthFlows `json:"flows,omitempty"`
+}
+
+type OpenAPIOAuthFlows struct {
+	Implicit          *OpenAPIOAuthFlow `json:"implicit,omitempty"`
+	Password          *OpenAPIOAuthFlow `json:"password,omitempty"`
+	ClientCredentials *OpenAPIOAuthFlow `json:"clientCredentials,omitempty"`
+	AuthorizationCode *OpenAPIOAuthFlow `json:"authorizationCode,omitempty"`
+}
+
+type OpenAPIOAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes"`
+}
+
+// SecurityRequirement asks for a scheme and, for OAuth2 and JWT schemes,
+// the scopes the token must grant.
+type SecurityRequirement struct {
+	Scheme SecurityScheme
+	Scopes []string
+}
+
+func Require(scheme SecurityScheme, scopes ...string) SecurityRequirement {
+	return SecurityRequirement{Scheme: scheme, Scopes: scopes}
+}
+
+// Secure sets the default requirements of the router's routes, replacing
+// those inherited by a group. Any one of them is enough to authenticate a
+// request; none makes the routes public.
+func (r *Router) Secure(requirements ...SecurityRequirement) {
+	r.security = append([]SecurityRequirement{}, requirements...)
+	r.generation.Add(1)
+}
+
+// Secured replaces the router's default requirements for one route.
+func Secured(requirements ...SecurityRequirement) RouteOption {
+	return func(rt *route) {
+		rt.security = append([]SecurityRequirement{}, requirements...)
+	}
+}
+
+// Public opts a route out of the router's default requirements.
+func Public() RouteOption {
+	return func(rt *route) {
+		rt.security = []SecurityRequirement{}
+	}
+}
+
+func securityFor(rt *route) []SecurityRequirement {
+	if rt.security != nil {
+		return rt.security
+	}
+	for group := rt.group; group != nil; group = group.parent {
+		if group.security != nil {
+			return group.security
+		}
+	}
+	return nil
+}
+
+// authenticate tries each requirement in turn and stores the first
+// principal that satisfies one.
+func authenticate(c *gin.Context, requirements []SecurityRequirement) error {
+	if len(requirements) == 0 {
+		return nil
+	}
+
+	var failure error = ErrUnauthorized
+	for _, requirement := range requirements {
+		principal, err := requirement.Scheme.Authenticate(c)
+		if errors.Is(err, errNoCredentials) {
+			continue
+		}
+		if err != nil {
+			failure = err
+			continue
+		}
+		if !hasScopes(principal, requirement.Scopes) {
+			failure = ErrInsufficientScope.WithDetails(gin.H{"required": requirement.Scopes})
+			continue
+		}
+		principal.Scheme = requirement.Scheme.Name()
+		c.Set(principalContextKey, principal)
+		return nil
+	}
+
+	var httpErr HTTPError
+	if errors.As(failure, &httpErr) && httpErr.HTTPStatus() == http.StatusUnauthorized {
+		for _, requirement := range requirements {
+			if challenge := requirement.Scheme.Challenge(); challenge != "" {
+				c.Writer.Header().Add("WWW-Authenticate", challenge)
+			}
+		}
+	}
+	return failure
+}
+
+func hasScopes(principal *Principal, scopes []string) bool {
+	for _, scope := range scopes {
+		if !principal.HasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func resolvePrincipal(c *gin.Context) (*Principal, error) {
+	principal := PrincipalFrom(c)
+	if principal == nil {
+		return nil, ErrUnauthorized
+	}
+	return principal, nil
+}
+
+// securitySchemes collects the schemes required by the router or any route,
+// sorted by name.
+func (r *Router) securitySchemes() []SecurityScheme {
+	byName := make(map[string]SecurityScheme)
+	collect := func(requirements []SecurityRequirement) {
+		for _, requirement := range requirements {
+			byName[requirement.Scheme.Name()] = requirement.Scheme
+		}
+	}
+	collect(r.security)
+	for _, methods := range r.routesMap {
+		for _, rt := range methods {
+			if r.documents(rt) {
+				collect(securityFor(rt))
+			}
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	schemes := make([]SecurityScheme, 0, len(names))
+	for _, name := range names {
+		schemes = append(schemes, byName[name])
+	}
+	return schemes
+}
+
+func securityRequirements(requirements []SecurityRequirement) []map[string][]string {
+	var converted []map[string][]string
+	for _, requirement := range requirements {
+		scopes := requirement.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+		converted = append(converted, map[string][]string{requirement.Scheme.Name(): scopes})
+	}
+	return converted
+}
+
+type apiKeyScheme struct {
+	name     string
+	in       string
+	param    string
+	validate func(c *gin.Context, key string) (*Principal, error)
+}
+
+// APIKeyAuth reads a key from the header, query or cookie named param and
+// lets validate turn it into a principal.
+func APIKeyAuth(name, in, param string, validate func(c *gin.Context, key string) (*Principal, error)) SecurityScheme {
+	switch in {
+	case "header", "query", "cookie":
+	default:
+		panic("API key must be read from header, query or cookie, not " + in)
+	}
+	return &apiKeyScheme{name: name, in: in, param: param, validate: validate}
+}
+
+func (s *apiKeyScheme) Name() string {
+	return s.name
+}
+
+func (s *apiKeyScheme) Authenticate(c *gin.Context) (*Principal, error) {
+	var key string
+	switch s.in {
+	case "header":
+		key = c.GetHeader(s.param)
+	case "query":
+		key = c.Query(s.param)
+	case "cookie":
+		key, _ = c.Cookie(s.param)
+	}
+	if key == "" {
+		return nil, errNoCredentials
+	}
+	principal, err := s.validate(c, key)
+	if err != nil {
+		return nil, err
+	}
+	if principal == nil {
+		return nil, ErrInvalidCredential
+	}
+	return principal, nil
+}
+
+func (s *apiKeyScheme) Challenge() string {
+	return ""
+}
+
+func (s *apiKeyScheme) SwaggerDefinition() *openapi.SecurityScheme {
+	if s.in == "cookie" {
+		return nil
+	}
+	return openapi.APIKeyAuth(s.param, s.in)
+}
+
+func (s *apiKeyScheme) OpenAPIDefinition() OpenAPISecurityScheme {
+	return OpenAPISecurityScheme{Type: "apiKey", Name: s.param, In: s.in}
+}
+
+type basicScheme struct {
+	name     string
+	realm    string
+	validate func(c *gin.Context, username, password string) (*Principal, error)
+}
+
+// BasicAuth checks HTTP basic credentials with validate.
+func BasicAuth(name, realm string, validate func(c *gin.Context, username, password string) (*Principal, error)) SecurityScheme {
+	return &basicScheme{name: name, realm: realm, validate: validate}
+}
+
+// BasicAuthAccounts is a BasicAuth backed by a fixed username to password
+// map, compared in constant time.
+func BasicAuthAccounts(name, realm string, accounts map[string]string) SecurityScheme {
+	return BasicAuth(name, realm, func(c *gin.Context, username, password string) (*Principal, error) {
+		expected, present := accounts[username]
+		if !present || subtle.ConstantTimeCompare([]byte(expected), []byte(password)) != 1 {
+			return nil, ErrInvalidCredential
+		}
+		return &Principal{Subject: username}, nil
+	})
+}
+
+func (s *basicScheme) Name() string {
+	return s.name
+}
+
+func (s *basicScheme) Authenticate(c *gin.Context) (*Principal, error) {
+	if !strings.HasPrefix(strings.ToLower(c.GetHeader("Authorization")), "basic ") {
+		return nil, errNoCredentials
+	}
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		return nil, ErrInvalidCredential
+	}
+	principal, err := s.validate(c, username, password)
+	if err != nil {
+		return nil, err
+	}
+	if principal == nil {
+		return nil, ErrInvalidCredential
+	}
+	return principal, nil
+}
+
+func (s *basicScheme) Challenge() string {
+	return `Basic realm="` + s.realm + `"`
+}
+
+func (s *basicScheme) SwaggerDefinition() *openapi.SecurityScheme {
+	return openapi.BasicAuth()
+}
+
+func (s *basicScheme) OpenAPIDefinition() OpenAPISecurityScheme {
+	return OpenAPISecurityScheme{Type: "http", Scheme: "basic"}
+}
+
+func bearerToken(c *gin.Context) (string, error) {
+	header := c.GetHeader("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return "", errNoCredentials
+	}
+	token := strings.TrimSpace(header[7:])
+	if token == "" {
+		return "", ErrInvalidCredential
+	}
+	return token, nil
+}
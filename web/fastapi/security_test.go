@@ -0,0 +1,333 @@
+package fastapi_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"web/fastapi"
+)
+
+var hmacSecret = []byte("fixture-secret")
+
+// signingKeys are the fixture keys of the tests' JWKS, generated once.
+var signingKeys = sync.OnceValues(func() (*rsa.PrivateKey, *ecdsa.PrivateKey) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return rsaKey, ecKey
+})
+
+func jwks() []byte {
+	rsaKey, ecKey := signingKeys()
+	encode := func(n *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(n.Bytes())
+	}
+	set := map[string]interface{}{"keys": []map[string]string{
+		{"kid": "rsa", "kty": "RSA", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+		{"kid": "ec", "kty": "EC", "use": "sig", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+		{"kid": "enc", "kty": "RSA", "use": "enc", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+	}}
+	data, _ := json.Marshal(set)
+	return data
+}
+
+// claims are valid claims for the tests' issuer and audience, changed by
+// edit.
+func claims(edit func(jwt.MapClaims)) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"sub":   "alice",
+		"iss":   "https://issuer.test",
+		"aud":   "api",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "read",
+	}
+	if edit != nil {
+		edit(claims)
+	}
+	return claims
+}
+
+// sign signs claims with the fixture key of method, setting kid if any.
+func sign(method jwt.SigningMethod, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	rsaKey, ecKey := signingKeys()
+	var key interface{}
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		key = hmacSecret
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key = rsaKey
+	case *jwt.SigningMethodECDSA:
+		key = ecKey
+	default:
+		key = jwt.UnsafeAllowNoneSignatureType
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+type whoami struct {
+	Subject string `json:"subject"`
+	Scheme  string `json:"scheme"`
+}
+
+type whoamiInput struct {
+	Principal *fastapi.Principal `depends:""`
+}
+
+func securedRouter(config fastapi.JWTConfig) *fastapi.Router {
+	r := fastapi.NewRouter()
+	bearer := fastapi.BearerJWT("bearer", config)
+	basic := fastapi.BasicAuthAccounts("basic", "api", map[string]string{"bob": "hunter2"})
+	r.Secure(fastapi.Require(bearer), fastapi.Require(basic))
+	me := func(c *gin.Context, in whoamiInput) (whoami, error) {
+		return whoami{Subject: in.Principal.Subject, Scheme: in.Principal.Scheme}, nil
+	}
+	r.Handle(http.MethodGet, "/me", me)
+	r.Handle(http.MethodPost, "/me", me, fastapi.Secured(fastapi.Require(bearer, "write")))
+	r.Handle(http.MethodGet, "/public", func(c *gin.Context, in struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	}, fastapi.Public())
+	for _, in := range []string{"header", "query", "cookie"} {
+		key := fastapi.APIKeyAuth(in+"Key", in, "api_key", func(c *gin.Context, key string) (*fastapi.Principal, error) {
+			if key != "k3y" {
+				return nil, nil
+			}
+			return &fastapi.Principal{Subject: "service"}, nil
+		})
+		r.Handle(http.MethodGet, "/"+in, me, fastapi.Secured(fastapi.Require(key)))
+	}
+	return r
+}
+
+func jwtConfig() fastapi.JWTConfig {
+	return fastapi.JWTConfig{
+		HMACSecret: hmacSecret,
+		JWKS:       jwks(),
+		Issuer:     "https://issuer.test",
+		Audience:   "api",
+		Leeway:     30 * time.Second,
+	}
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func TestBearerJWT(t *testing.T) {
+	expired := claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() })
+	tests := []struct {
+		name   string
+		method string
+		header http.Header
+		status int
+		code   string
+	}{
+		{"HS256", http.MethodGet, bearer(sign(jwt.SigningMethodHS256, "", claims(nil))), http.StatusOK, ""},
+		{"HS512", http.MethodGet, bearer(sign(jwt.SigningMethodHS512, "", claims(nil))), http.StatusOK, ""},
+		{"RS256 from the JWKS", http.MethodGet, bearer(sign(jwt.SigningMethodRS256, "rsa", claims(nil))), http.StatusOK, ""},
+		{"PS256 from the JWKS", http.MethodGet, bearer(sign(jwt.SigningMethodPS256, "rsa", claims(nil))), http.StatusOK, ""},
+		{"ES256 from the JWKS", http.MethodGet, bearer(sign(jwt.SigningMethodES256, "ec", claims(nil))), http.StatusOK, ""},
+		{"lowercase scheme", http.MethodGet, http.Header{"Authorization": {"bearer " + sign(jwt.SigningMethodHS256, "", claims(nil))}}, http.StatusOK, ""},
+		{"unknown key", http.MethodGet, bearer(sign(jwt.SigningMethodRS256, "other", claims(nil))), http.StatusUnauthorized, "invalid_credentials"},
+		{"encryption key", http.MethodGet, bearer(sign(jwt.SigningMethodRS256, "enc", claims(nil))), http.StatusUnauthorized, "invalid_credentials"},
+		{"none algorithm", http.MethodGet, bearer(sign(jwt.SigningMethodNone, "", claims(nil))), http.StatusUnauthorized, "invalid_credentials"},
+		{"tampered", http.MethodGet, bearer(sign(jwt.SigningMethodHS256, "", claims(nil)) + "x"), http.StatusUnauthorized, "invalid_credentials"},
+		{"expired", http.MethodGet, bearer(sign(jwt.SigningMethodHS256, "", expired)), http.StatusUnauthorized, "invalid_credentials"},
+		{"expired within leeway", http.MethodGet, bearer(sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-10 * time.Second).Unix() }))), http.StatusOK, ""},
+		{"not yet valid", http.MethodGet, bearer(sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Hour).Unix() }))), http.StatusUnauthorized, "invalid_credentials"},
+		{"no expiry", http.MethodGet, bearer(sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { delete(c, "exp") }))), http.StatusUnauthorized, "invalid_credentials"},
+		{"other issuer", http.MethodGet, bearer(sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { c["iss"] = "https://evil.test" }))), http.StatusUnauthorized, "invalid_credentials"},
+		{"other audience", http.MethodGet, bearer(sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { c["aud"] = "other" }))), http.StatusUnauthorized, "invalid_credentials"},
+		{"audience list", http.MethodGet, bearer(sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { c["aud"] = []string{"other", "api"} }))), http.StatusOK, ""},
+		{"empty token", http.MethodGet, http.Header{"Authorization": {"Bearer "}}, http.StatusUnauthorized, ""},
+		{"no token", http.MethodGet, nil, http.StatusUnauthorized, "unauthorized"},
+		{"missing scope", http.MethodPost, bearer(sign(jwt.SigningMethodHS256, "", claims(nil))), http.StatusForbidden, "insufficient_scope"},
+		{"scope", http.MethodPost, bearer(sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { c["scope"] = "read write" }))), http.StatusOK, ""},
+		{"scp list", http.MethodPost, bearer(sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+			delete(c, "scope")
+			c["scp"] = []string{"write"}
+		}))), http.StatusOK, ""},
+	}
+	r := securedRouter(jwtConfig())
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(r.GinHandler, test.method, "/me", "", test.header)
+			expectStatus(t, recorder, test.status)
+			if test.status == http.StatusOK {
+				var out whoami
+				decode(t, recorder, &out)
+				if out != (whoami{Subject: "alice", Scheme: "bearer"}) {
+					t.Errorf("principal %+v, want alice from bearer", out)
+				}
+				return
+			}
+			if test.code != "" {
+				if code := problem(t, recorder).Code; code != test.code {
+					t.Errorf("code %q, want %q", code, test.code)
+				}
+			}
+		})
+	}
+}
+
+func TestJWTAlgorithmsFollowKeys(t *testing.T) {
+	// Without an HMAC secret, HMAC tokens are refused whatever they are
+	// signed with, even the public key of the JWKS.
+	rsaKey, _ := signingKeys()
+	public, _ := json.Marshal(rsaKey.PublicKey)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(nil)).SignedString(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := securedRouter(fastapi.JWTConfig{JWKS: jwks(), Audience: "api"})
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/me", "", bearer(token)), http.StatusUnauthorized)
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/me", "", bearer(sign(jwt.SigningMethodHS256, "", claims(nil)))), http.StatusUnauthorized)
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/me", "", bearer(sign(jwt.SigningMethodES256, "ec", claims(nil)))), http.StatusOK)
+
+	// And without a JWKS, public key algorithms are.
+	r = securedRouter(fastapi.JWTConfig{HMACSecret: hmacSecret})
+	expectStatus(t, serve(r.GinHandler, http.MethodGet, "/me", "", bearer(sign(jwt.SigningMethodRS256, "rsa", claims(nil)))), http.StatusUnauthorized)
+}
+
+func TestJWTConfigPanics(t *testing.T) {
+	tests := []struct {
+		name   string
+		config fastapi.JWTConfig
+	}{
+		{"no keys", fastapi.JWTConfig{}},
+		{"invalid JWKS", fastapi.JWTConfig{JWKS: []byte(`{"keys": [{"kty": "EC", "crv": "P-256", "x": "AA", "y": "AA"}]}`)}},
+		{"no signing keys", fastapi.JWTConfig{JWKS: []byte(`{"keys": []}`)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("configured without panicking")
+				}
+			}()
+			fastapi.BearerJWT("bearer", test.config)
+		})
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	r := securedRouter(jwtConfig())
+	tests := []struct {
+		name       string
+		target     string
+		header     http.Header
+		challenges string
+	}{
+		{"every scheme is offered", "/me", nil, `Bearer, Basic realm="api"`},
+		{"invalid credentials", "/me", bearer("nope"), `Bearer, Basic realm="api"`},
+		{"API keys have none", "/header", nil, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodGet, test.target, "", test.header)
+			expectStatus(t, recorder, http.StatusUnauthorized)
+			if challenges := strings.Join(recorder.Header().Values("WWW-Authenticate"), ", "); challenges != test.challenges {
+				t.Errorf("WWW-Authenticate %q, want %q", challenges, test.challenges)
+			}
+		})
+	}
+	// Scopes are not a matter of authentication.
+	recorder := serve(r.GinHandler, http.MethodPost, "/me", "", bearer(sign(jwt.SigningMethodHS256, "", claims(nil))))
+	expectStatus(t, recorder, http.StatusForbidden)
+	if challenges := recorder.Header().Values("WWW-Authenticate"); len(challenges) > 0 {
+		t.Errorf("WWW-Authenticate %q on 403", challenges)
+	}
+}
+
+func TestBasicAndAPIKeyAuth(t *testing.T) {
+	basic := func(username, password string) http.Header {
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		return http.Header{"Authorization": {"Basic " + credentials}}
+	}
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+		status int
+		want   whoami
+	}{
+		{"basic", "/me", basic("bob", "hunter2"), http.StatusOK, whoami{"bob", "basic"}},
+		{"basic wrong password", "/me", basic("bob", "hunter3"), http.StatusUnauthorized, whoami{}},
+		{"basic unknown user", "/me", basic("eve", "hunter2"), http.StatusUnauthorized, whoami{}},
+		{"basic malformed", "/me", http.Header{"Authorization": {"Basic !!"}}, http.StatusUnauthorized, whoami{}},
+		{"header key", "/header", http.Header{"Api_key": {"k3y"}}, http.StatusOK, whoami{"service", "headerKey"}},
+		{"query key", "/query?api_key=k3y", nil, http.StatusOK, whoami{"service", "queryKey"}},
+		{"cookie key", "/cookie", http.Header{"Cookie": {"api_key=k3y"}}, http.StatusOK, whoami{"service", "cookieKey"}},
+		{"invalid key", "/query?api_key=nope", nil, http.StatusUnauthorized, whoami{}},
+		{"key of another scheme", "/query", http.Header{"Api_key": {"k3y"}}, http.StatusUnauthorized, whoami{}},
+		{"public", "/public", nil, http.StatusOK, whoami{}},
+	}
+	r := securedRouter(jwtConfig())
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodGet, test.target, "", test.header)
+			expectStatus(t, recorder, test.status)
+			if test.want != (whoami{}) {
+				var out whoami
+				decode(t, recorder, &out)
+				if out != test.want {
+					t.Errorf("principal %+v, want %+v", out, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSecuritySpec(t *testing.T) {
+	r := securedRouter(jwtConfig())
+	spec := r.EmitOpenAPIDefinition()
+	for _, name := range []string{"bearer", "basic", "headerKey", "queryKey"} {
+		if _, present := spec.SecurityDefinitions[name]; !present {
+			t.Errorf("Swagger security definitions lack %s", name)
+		}
+	}
+	// Swagger 2.0 cannot describe cookie keys.
+	if _, present := spec.SecurityDefinitions["cookieKey"]; present {
+		t.Error("Swagger security definitions describe cookieKey")
+	}
+	if security := spec.Paths.Paths["/me"].Post.Security; len(security) != 1 || len(security[0]["bearer"]) != 1 {
+		t.Errorf("POST /me security %v, want bearer with the write scope", security)
+	}
+	if security := spec.Paths.Paths["/public"].Get.Security; len(security) != 0 {
+		t.Errorf("GET /public security %v, want none", security)
+	}
+	if _, present := spec.Paths.Paths["/me"].Post.Responses.StatusCodeResponses[http.StatusForbidden]; !present {
+		t.Error("POST /me does not document 403")
+	}
+
+	schemes := r.EmitOpenAPI31Definition().Components.SecuritySchemes
+	if scheme := schemes["cookieKey"]; scheme.Type != "apiKey" || scheme.In != "cookie" {
+		t.Errorf("cookieKey scheme %+v", scheme)
+	}
+	if scheme := schemes["bearer"]; scheme.Scheme != "bearer" || scheme.BearerFormat != "JWT" {
+		t.Errorf("bearer scheme %+v", scheme)
+	}
+}
@@ -0,0 +1,103 @@
+package fastapi
+
+import (
+	"strings"
+)
+
+// node is one path segment of the route tree. Static children are matched
+// before the parameter child, so /users/me wins over /users/{id}.
+type node struct {
+	children  map[string]*node
+	param     *node
+	paramName string
+	pattern   string
+	methods   map[string]*route
+}
+
+func newNode() *node {
+	return &node{children: make(map[string]*node)}
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func paramName(segment string) (string, bool) {
+	if len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}' {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+func pathParamNames(pattern string) []string {
+	var names []string
+	for _, segment := range splitPath(pattern) {
+		if name, ok := paramName(segment); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (n *node) insert(pattern string) *node {
+	current := n
+	for _, segment := range splitPath(pattern) {
+		if name, ok := paramName(segment); ok {
+			if current.param == nil {
+				current.param = newNode()
+				current.param.paramName = name
+			} else if current.param.paramName != name {
+				panic("Conflicting path parameter {" + name + "} in " + pattern +
+					", already registered as {" + current.param.paramName + "}")
+			}
+			current = current.param
+			continue
+		}
+		child, present := current.children[segment]
+		if !present {
+			child = newNode()
+			current.children[segment] = child
+		}
+		current = child
+	}
+	if current.methods == nil {
+		current.pattern = pattern
+		current.methods = make(map[string]*route)
+	}
+	return current
+}
+
+func (n *node) lookup(path string) (*node, map[string]string) {
+	params := make(map[string]string)
+	found := n.match(splitPath(path), params)
+	if found == nil {
+		return nil, nil
+	}
+	return found, params
+}
+
+func (n *node) match(segments []string, params map[string]string) *node {
+	if len(segments) == 0 {
+		if n.methods == nil {
+			return nil
+		}
+		return n
+	}
+	segment := segments[0]
+	if child, present := n.children[segment]; present {
+		if found := child.match(segments[1:], params); found != nil {
+			return found
+		}
+	}
+	if n.param != nil {
+		if found := n.param.match(segments[1:], params); found != nil {
+			params[n.param.paramName] = segment
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package fastapi
+
+import (
+	"encoding"
+	"encoding/json"
+	openapi "github.com/go-openapi/spec"
+	"reflect"
+)
+
+// SchemaProvider is implemented by types describing their own JSON schema,
+// typically those with a custom MarshalJSON.
+type SchemaProvider interface {
+	OpenAPISchema() openapi.Schema
+}
+
+// builtinSchemas describe common types whose JSON encoding differs from
+// their Go structure, by package path and name so that their packages need
+// not be imported. sql.Null* types are described by their structure, the
+// objects encoding/json writes for them: NullString and the other Null*
+// wrappers encode and describe them as nullable scalars.
+var builtinSchemas = map[string]func() *openapi.Schema{
+	"time.Time": func() *openapi.Schema { return openapi.DateTimeProperty() },
+	"time.Duration": func() *openapi.Schema {
+		return openapi.Int64Property().WithDescription("Duration in nanoseconds")
+	},
+	"encoding/json.RawMessage":              func() *openapi.Schema { return &openapi.Schema{} },
+	"encoding/json.Number":                  func() *openapi.Schema { return new(openapi.Schema).Typed("number", "") },
+	"github.com/google/uuid.UUID":           uuidSchema,
+	"github.com/gofrs/uuid.UUID":            uuidSchema,
+	"github.com/satori/go.uuid.UUID":        uuidSchema,
+	"github.com/shopspring/decimal.Decimal": decimalSchema,
+	"github.com/shopspring/decimal.NullDecimal": func() *openapi.Schema {
+		schema := withExtension(*decimalSchema(), "x-nullable", true)
+		return &schema
+	},
+}
+
+func uuidSchema() *openapi.Schema {
+	return openapi.StrFmtProperty("uuid")
+}
+
+func decimalSchema() *openapi.Schema {
+	return openapi.StrFmtProperty("decimal")
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	schemaProvider    = reflect.TypeOf((*SchemaProvider)(nil)).Elem()
+)
+
+// DescribeType sets the schema of T wherever it appears in the router's
+// spec, taking precedence over SchemaProvider and the built-in schemas.
+func DescribeType[T any](r *Router, schema openapi.Schema) {
+	r.schemas[reflect.TypeOf((*T)(nil)).Elem()] = schema
+	r.generation.Add(1)
+}
+
+// Enum documents T, a type of Go constants, as an enum of values:
+//
+//	type Color string
+//
+//	const (
+//		Red  Color = "red"
+//		Blue Color = "blue"
+//	)
+//
+//	fastapi.Enum(r, Red, Blue)
+func Enum[T any](r *Router, values ...T) {
+	goType := reflect.TypeOf((*T)(nil)).Elem()
+	schema := newSchemaGenerator(nil).schemaFor(goType)
+	for _, value := range values {
+		// The enum lists values as they are encoded, honoring marshalers.
+		data, err := json.Marshal(value)
+		if err != nil {
+			panic("Enum value cannot be encoded: " + err.Error())
+		}
+		var encoded interface{}
+		json.Unmarshal(data, &encoded)
+		schema.Enum = append(schema.Enum, encoded)
+	}
+	DescribeType[T](r, schema)
+}
+
+// knownSchema returns the schema of types not described by their structure:
+// registered and built-in types, SchemaProviders, and marshalers, which are
+// strings when they marshal to text and anything otherwise.
+func (g *schemaGenerator) knownSchema(goType reflect.Type) *openapi.Schema {
+	if schema, present := g.types[goType]; present {
+		return &schema
+	}
+	if goType.Kind() == reflect.Interface {
+		return nil
+	}
+	if goType.Implements(schemaProvider) {
+		schema := reflect.Zero(goType).Interface().(SchemaProvider).OpenAPISchema()
+		return &schema
+	}
+	if builtin, present := builtinSchemas[goType.PkgPath()+"."+goType.Name()]; present {
+		return builtin()
+	}
+	switch {
+	case goType.Implements(jsonMarshalerType):
+		return &openapi.Schema{}
+	case goType.Implements(textMarshalerType):
+		return openapi.StringProperty()
+	case goType.Kind() == reflect.Slice && goType.Elem().Kind() == reflect.Uint8:
+		// encoding/json writes []byte as base64.
+		return openapi.StrFmtProperty("byte")
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package fastapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	openapi "github.com/go-openapi/spec"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Validation rules are declared on input fields:
+//
+//	Name  string   `json:"name" validate:"required,minlen=1,maxlen=64"`
+//	Age   int      `json:"age" validate:"min=0,max=150"`
+//	Email string   `json:"email" validate:"email"`
+//	Kind  string   `json:"kind" validate:"enum=a|b|c"`
+//	Code  string   `json:"code" pattern:"^[A-Z]{3}$"`
+//
+// required makes parameters mandatory even with a default, and body fields
+// present and not null, any other value being accepted, zero included.
+// min/max bound numbers, minlen/maxlen bound string lengths and slice sizes.
+// The other rules of slices apply to each of their items.
+type constraints struct {
+	required bool
+	min      *float64
+	max      *float64
+	minLen   *int64
+	maxLen   *int64
+	enum     []string
+	format   string
+	pattern  *regexp.Regexp
+}
+
+type FieldError struct {
+	In     string `json:"in"`
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		reasons = append(reasons, fmt.Sprintf("%s %s: %s", fieldErr.In, fieldErr.Path, fieldErr.Reason))
+	}
+	return "validation failed: " + strings.Join(reasons, "; ")
+}
+
+func (e *ValidationError) add(in, path, reason string) {
+	e.Errors = append(e.Errors, FieldError{In: in, Path: path, Reason: reason})
+}
+
+// items returns the constraints of the items of a slice, leaving out those
+// of the slice itself.
+func (cons constraints) items() constraints {
+	cons.required = false
+	cons.minLen, cons.maxLen = nil, nil
+	return cons
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var constraintsCache sync.Map
+
+func parseConstraints(tag reflect.StructTag) constraints {
+	key := string(tag)
+	if cached, present := constraintsCache.Load(key); present {
+		return cached.(constraints)
+	}
+
+	var cons constraints
+	for _, rule := range strings.Split(tag.Get("validate"), ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+		case "required":
+			cons.required = true
+		case "min", "max":
+			value, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic("Invalid validate rule " + rule)
+			}
+			if name == "min" {
+				cons.min = &value
+			} else {
+				cons.max = &value
+			}
+		case "minlen", "maxlen":
+			value, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				panic("Invalid validate rule " + rule)
+			}
+			if name == "minlen" {
+				cons.minLen = &value
+			} else {
+				cons.maxLen = &value
+			}
+		case "enum":
+			cons.enum = strings.Split(arg, "|")
+		case "email", "uuid":
+			cons.format = name
+		default:
+			panic("Unknown validate rule " + rule)
+		}
+	}
+	if pattern, present := tag.Lookup("pattern"); present {
+		cons.pattern = regexp.MustCompile(pattern)
+	}
+
+	constraintsCache.Store(key, cons)
+	return cons
+}
+
+// checkConstraints parses the validation rules of every field of goType and
+// of the types it refers to, so that invalid rules fail at registration
+// instead of failing every request.
+func checkConstraints(goType reflect.Type, visited map[reflect.Type]bool) {
+	for goType.Kind() == reflect.Ptr || goType.Kind() == reflect.Slice || goType.Kind() == reflect.Array || goType.Kind() == reflect.Map {
+		goType = goType.Elem()
+	}
+	if goType.Kind() != reflect.Struct || visited[goType] {
+		return
+	}
+	visited[goType] = true
+	for i := 0; i < goType.NumField(); i++ {
+		if field := goType.Field(i); field.IsExported() || field.Anonymous {
+			parseConstraints(field.Tag)
+			checkConstraints(field.Type, visited)
+		}
+	}
+}
+
+// bodyError turns a JSON decoding failure into a *ValidationError when it
+// can be pinned to a field, and returns nil for malformed documents.
+func bodyError(err error) *ValidationError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		verr := &ValidationError{}
+		verr.add("body", typeErr.Field, "expected "+typeErr.Type.String()+", got "+typeErr.Value)
+		return verr
+	}
+	return nil
+}
+
+// validateInput checks every field of the bound input struct and collects all
+// failures instead of stopping at the first one. body is the JSON the input
+// was decoded from, nil when the route reads none.
+func validateInput(inputVal reflect.Value, body []byte) *ValidationError {
+	verr := &ValidationError{}
+	for _, in := range parameterLocations {
+		eachTaggedField(inputVal, in, func(name string, field reflect.Value, structField reflect.StructField) error {
+			cons := parseConstraints(structField.Tag)
+			// Required parameters were found present when bound.
+			cons.required = false
+			validateValue(verr, in, name, field, cons, nil)
+			return nil
+		})
+	}
+	if body != nil {
+		var raw interface{}
+		json.Unmarshal(body, &raw)
+		validateValue(verr, "body", "", inputVal, constraints{}, raw)
+	}
+	if len(verr.Errors) == 0 {
+		return nil
+	}
+	return verr
+}
+
+// validateStruct checks the fields of a struct decoded from raw, the
+// generic JSON telling which fields were sent. Presence is not checked
+// without it.
+func validateStruct(verr *ValidationError, in, path string, structVal reflect.Value, raw interface{}) {
+	sent, known := raw.(map[string]interface{})
+	structType := structVal.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() || isNonBodyField(field) {
+			continue
+		}
+		name := jsonFieldName(field)
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			validateStruct(verr, in, path, structVal.Field(i), raw)
+			continue
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		cons := parseConstraints(field.Tag)
+		if known && cons.required && sent[name] == nil {
+			verr.add(in, fieldPath, "field required")
+			continue
+		}
+		validateValue(verr, in, fieldPath, structVal.Field(i), cons, sent[name])
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// validateValue checks a value against its constraints, all but required,
+// which is checked on the struct holding it. raw is the generic JSON the
+// value was decoded from, if any.
+func validateValue(verr *ValidationError, in, path string, value reflect.Value, cons constraints, raw interface{}) {
+	if value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if !value.IsNil() {
+			validateValue(verr, in, path, value.Elem(), cons, raw)
+		}
+		return
+	}
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		checkRange(verr, in, path, float64(value.Int()), cons)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		checkRange(verr, in, path, float64(value.Uint()), cons)
+	case reflect.Float32, reflect.Float64:
+		checkRange(verr, in, path, value.Float(), cons)
+	case reflect.String:
+		str := value.String()
+		checkLength(verr, in, path, int64(len([]rune(str))), "characters", cons)
+		if cons.pattern != nil && !cons.pattern.MatchString(str) {
+			verr.add(in, path, "does not match pattern "+cons.pattern.String())
+		}
+		if str != "" {
+			checkFormat(verr, in, path, str, cons.format)
+		}
+	case reflect.Slice, reflect.Array:
+		checkLength(verr, in, path, int64(value.Len()), "items", cons)
+		items, _ := raw.([]interface{})
+		for i := 0; i < value.Len(); i++ {
+			var item interface{}
+			if i < len(items) {
+				item = items[i]
+			}
+			validateValue(verr, in, fmt.Sprintf("%s[%d]", path, i), value.Index(i), cons.items(), item)
+		}
+		return
+	case reflect.Map:
+		checkLength(verr, in, path, int64(value.Len()), "items", cons)
+		entries, _ := raw.(map[string]interface{})
+		iter := value.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key())
+			validateValue(verr, in, path+"["+key+"]", iter.Value(), constraints{}, entries[key])
+		}
+		return
+	case reflect.Struct:
+		validateStruct(verr, in, path, value, raw)
+		return
+	}
+
+	if len(cons.enum) > 0 {
+		actual := fmt.Sprint(value.Interface())
+		for _, allowed := range cons.enum {
+			if actual == allowed {
+				return
+			}
+		}
+		verr.add(in, path, "must be one of "+strings.Join(cons.enum, ", "))
+	}
+}
+
+func checkRange(verr *ValidationError, in, path string, value float64, cons constraints) {
+	if cons.min != nil && value < *cons.min {
+		verr.add(in, path, fmt.Sprintf("must be greater than or equal to %v", *cons.min))
+	}
+	if cons.max != nil && value > *cons.max {
+		verr.add(in, path, fmt.Sprintf("must be less than or equal to %v", *cons.max))
+	}
+}
+
+func checkLength(verr *ValidationError, in, path string, length int64, unit string, cons constraints) {
+	if cons.minLen != nil && length < *cons.minLen {
+		verr.add(in, path, fmt.Sprintf("must have at least %d %s", *cons.minLen, unit))
+	}
+	if cons.maxLen != nil && length > *cons.maxLen {
+		verr.add(in, path, fmt.Sprintf("must have at most %d %s", *cons.maxLen, unit))
+	}
+}
+
+func checkFormat(verr *ValidationError, in, path, value, format string) {
+	switch format {
+	case "email":
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Address != value {
+			verr.add(in, path, "must be a valid email address")
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(value) {
+			verr.add(in, path, "must be a valid UUID")
+		}
+	}
+}
+
+// applyConstraints copies the validation rules of a field onto the schema or
+// parameter describing it. Only the sizes of slices are copied, the other
+// rules go to their items, with cons.items().
+func applyConstraints(validations *openapi.CommonValidations, format *string, fieldType reflect.Type, cons constraints) {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	switch fieldType.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if cons.minLen != nil {
+			validations.MinItems = cons.minLen
+		}
+		if cons.maxLen != nil {
+			validations.MaxItems = cons.maxLen
+		}
+		if fieldType.Kind() != reflect.Map {
+			return
+		}
+	default:
+		if cons.minLen != nil {
+			validations.MinLength = cons.minLen
+		}
+		if cons.maxLen != nil {
+			validations.MaxLength = cons.maxLen
+		}
+	}
+	if cons.min != nil {
+		validations.Minimum = cons.min
+	}
+	if cons.max != nil {
+		validations.Maximum = cons.max
+	}
+	if cons.pattern != nil {
+		validations.Pattern = cons.pattern.String()
+	}
+	if len(cons.enum) > 0 {
+		validations.Enum = nil
+	}
+	for _, allowed := range cons.enum {
+		value := reflect.New(fieldType).Elem()
+		if err := setFromString(value, allowed); err != nil {
+			validations.Enum = append(validations.Enum, allowed)
+			continue
+		}
+		validations.Enum = append(validations.Enum, value.Interface())
+	}
+	if cons.format != "" {
+		*format = cons.format
+	}
+}
@@ -0,0 +1,198 @@
+package fastapi_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"testing"
+	"web/fastapi"
+)
+
+type validateInput struct {
+	Kind  string   `query:"kind" default:"a" validate:"enum=a|b"`
+	Tags  []string `query:"tag" validate:"enum=a|b,maxlen=2"`
+	Sizes []int    `query:"size" validate:"min=1,max=9"`
+	Codes []string `query:"code" pattern:"^[A-Z]{3}$"`
+	Page  int      `query:"page" validate:"required,min=0"`
+	Name  string   `json:"name" validate:"required,maxlen=8"`
+	Email string   `json:"email" validate:"email"`
+	Items []string `json:"items" validate:"minlen=1,enum=x|y"`
+}
+
+func validateRouter() *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodPost, "/things", func(c *gin.Context, in validateInput) (struct{}, error) {
+		return struct{}{}, nil
+	})
+	return r
+}
+
+func TestValidate(t *testing.T) {
+	const valid = `{"name": "n", "items": ["x"]}`
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		// failures are the "in path" of the expected field errors.
+		failures []string
+	}{
+		{"valid", "/things?page=1&tag=a&tag=b&size=3&code=ABC", valid, nil},
+		{"required zero parameter is present", "/things?page=0", valid, nil},
+		{"required parameter missing", "/things", valid, []string{"query page"}},
+		{"scalar enum", "/things?page=1&kind=c", valid, []string{"query kind"}},
+		{"slice item enum", "/things?page=1&tag=zzz", valid, []string{"query tag[0]"}},
+		{"slice size", "/things?page=1&tag=a&tag=a&tag=b", valid, []string{"query tag"}},
+		{"slice item range", "/things?page=1&size=1&size=10", valid, []string{"query size[1]"}},
+		{"slice item pattern", "/things?page=1&code=ABC&code=abc", valid, []string{"query code[1]"}},
+		{"minimum", "/things?page=-1", valid, []string{"query page"}},
+		{"required body field", "/things?page=1", `{"items": ["x"]}`, []string{"body name"}},
+		{"required body field is null", "/things?page=1", `{"name": null, "items": ["x"]}`, []string{"body name"}},
+		{"required zero body field is present", "/things?page=1", `{"name": "", "items": ["x"]}`, nil},
+		{"body length", "/things?page=1", `{"name": "too long a name", "items": ["x"]}`, []string{"body name"}},
+		{"body format", "/things?page=1", `{"name": "n", "email": "nope", "items": ["x"]}`, []string{"body email"}},
+		{"body slice size", "/things?page=1", `{"name": "n", "items": []}`, []string{"body items"}},
+		{"body slice items", "/things?page=1", `{"name": "n", "items": ["x", "z"]}`, []string{"body items[1]"}},
+		{"every failure", "/things?page=-1&kind=c", `{"items": []}`, []string{"query kind", "query page", "body name", "body items"}},
+	}
+	r := validateRouter()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(r.GinHandler, http.MethodPost, test.target, test.body, nil)
+			if len(test.failures) == 0 {
+				expectStatus(t, recorder, http.StatusOK)
+				return
+			}
+			expectStatus(t, recorder, http.StatusUnprocessableEntity)
+			details, _ := problem(t, recorder).Details.([]interface{})
+			var got []string
+			for _, detail := range details {
+				fieldErr := detail.(map[string]interface{})
+				got = append(got, fieldErr["in"].(string)+" "+fieldErr["path"].(string))
+			}
+			if strings.Join(got, ", ") != strings.Join(test.failures, ", ") {
+				t.Errorf("failures %v, want %v", got, test.failures)
+			}
+		})
+	}
+}
+
+func TestValidateSpec(t *testing.T) {
+	spec := validateRouter().EmitOpenAPIDefinition()
+	op := spec.Paths.Paths["/things"].Post
+	for _, param := range op.Parameters {
+		switch param.Name {
+		case "tag":
+			if len(param.Enum) > 0 || param.Items == nil || len(param.Items.Enum) != 2 {
+				t.Errorf("tag enum %v, items %+v: want the enum on the items", param.Enum, param.Items)
+			}
+			if param.MaxItems == nil || *param.MaxItems != 2 {
+				t.Errorf("tag maxItems %v, want 2", param.MaxItems)
+			}
+		case "size":
+			if param.Minimum != nil || param.Items.Minimum == nil || *param.Items.Minimum != 1 {
+				t.Errorf("size minimum %v, items minimum %v: want it on the items", param.Minimum, param.Items.Minimum)
+			}
+		case "page":
+			if !param.Required {
+				t.Errorf("page is not required")
+			}
+		}
+	}
+	items := spec.Definitions["fastapi_test.validateInput"].Properties["items"]
+	if len(items.Enum) > 0 || items.Items == nil || len(items.Items.Schema.Enum) != 2 {
+		t.Errorf("items enum %v: want the enum on the items", items.Enum)
+	}
+	if items.MinItems == nil || *items.MinItems != 1 {
+		t.Errorf("items minItems %v, want 1", items.MinItems)
+	}
+}
+
+func TestValidateRulesCheckedAtRegistration(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler interface{}
+	}{
+		{"unknown rule on a parameter", func(c *gin.Context, in struct {
+			Name string `query:"name" validate:"requird"`
+		}) (struct{}, error) {
+			return struct{}{}, nil
+		}},
+		{"unknown rule on a nested body field", func(c *gin.Context, in struct {
+			Inner []struct {
+				Name string `json:"name" validate:"max=x"`
+			} `json:"inner"`
+		}) (struct{}, error) {
+			return struct{}{}, nil
+		}},
+		{"invalid pattern", func(c *gin.Context, in struct {
+			Name string `json:"name" pattern:"("`
+		}) (struct{}, error) {
+			return struct{}{}, nil
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("registered without panicking")
+				}
+			}()
+			fastapi.NewRouter().Handle(http.MethodPost, "/things", test.handler)
+		})
+	}
+}
+
+type countsInput struct {
+	Count   int            `json:"count" validate:"required"`
+	Limit   *int           `json:"limit" validate:"required"`
+	Enabled bool           `json:"enabled" validate:"required"`
+	Nested  []countsNested `json:"nested"`
+}
+
+type countsNested struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func countsRouter(mode fastapi.ContractMode) *fastapi.Router {
+	r := fastapi.NewRouter()
+	r.Handle(http.MethodPost, "/counts", func(c *gin.Context, in countsInput) (struct{}, error) {
+		return struct{}{}, nil
+	})
+	r.CheckContract(mode)
+	return r
+}
+
+// TestValidateRequiredBody checks that required body fields are held to
+// the spec: present and not null, zero values included.
+func TestValidateRequiredBody(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{"zero values", `{"count": 0, "limit": 0, "enabled": false}`, true},
+		{"missing", `{"limit": 0, "enabled": false}`, false},
+		{"null", `{"count": 0, "limit": null, "enabled": false}`, false},
+		{"nested zero value", `{"count": 0, "limit": 0, "enabled": false, "nested": [{"name": ""}]}`, true},
+		{"nested missing", `{"count": 0, "limit": 0, "enabled": false, "nested": [{}]}`, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(countsRouter(fastapi.ContractOff).GinHandler, http.MethodPost, "/counts", test.body, nil)
+			if test.valid {
+				expectStatus(t, recorder, http.StatusOK)
+			} else {
+				expectStatus(t, recorder, http.StatusUnprocessableEntity)
+			}
+
+			// The spec agrees: enforcing it rejects the same requests,
+			// before validation runs.
+			recorder = serve(countsRouter(fastapi.ContractEnforce).GinHandler, http.MethodPost, "/counts", test.body, nil)
+			if test.valid {
+				expectStatus(t, recorder, http.StatusOK)
+			} else {
+				expectStatus(t, recorder, http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package fastapi
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// versioning holds the API versions of a router and the traffic of its
+// deprecated routes, shared by the router and its groups.
+type versioning struct {
+	names    map[string]bool
+	header   string
+	fallback string
+
+	mu    sync.Mutex
+	usage map[*route]*DeprecatedUsage
+}
+
+// Version returns a group serving version name of the API under /name, e.g.
+// Version("v1") under /v1. Its GinHandler serves the version's routes only,
+// the root router's serves every version. Deprecating a whole version is
+// done with its Defaults, e.g. v1.Defaults(DeprecatedSince(date, ""),
+// Sunset(date)).
+func (r *Router) Version(name string) *Router {
+	group := r.Group(name)
+	group.version = name
+	r.versions.names[name] = true
+	return group
+}
+
+// VersionHeader also selects versions with a request header, e.g.
+// "API-Version", for paths without a version prefix: /users is then served
+// by /v2/users when the header is "v2", and by the fallback version when
+// the header is missing.
+func (r *Router) VersionHeader(header, fallback string) {
+	r.versions.header = header
+	r.versions.fallback = fallback
+}
+
+// versionedPath returns the path serving a request, adding the version
+// prefix chosen by the version header. Paths already naming a version, and
+// those of routes outside the chosen version, are left alone.
+func (r *Router) versionedPath(c *gin.Context, path string) (string, error) {
+	header := r.versions.header
+	if header == "" {
+		return path, nil
+	}
+	if segments := splitPath(path); len(segments) > 0 && r.versions.names[segments[0]] {
+		return path, nil
+	}
+	c.Writer.Header().Add("Vary", header)
+	version := c.GetHeader(header)
+	if version == "" {
+		version = r.versions.fallback
+	} else if !r.versions.names[version] {
+		names := make([]string, 0, len(r.versions.names))
+		for name := range r.versions.names {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", NewError(http.StatusBadRequest, "unknown_version", "unknown API version "+strconv.Quote(version)).
+			WithDetails(gin.H{"versions": names})
+	}
+	if found, _ := r.tree.lookup(joinPath(version, path)); found != nil {
+		return joinPath(version, path), nil
+	}
+	return path, nil
+}
+
+// versionName returns the version a router's routes belong to.
+func (r *Router) versionName() string {
+	for current := r; current != nil; current = current.parent {
+		if current.version != "" {
+			return current.version
+		}
+	}
+	return ""
+}
+
+// documents reports whether the router's spec describes the route: version
+// routers describe their own version only.
+func (r *Router) documents(rt *route) bool {
+	return r.version == "" || rt.group.versionName() == r.version
+}
+
+// served returns the routes of methods the router serves: version routers
+// and their groups serve their own version only.
+func (r *Router) served(methods map[string]*route) map[string]*route {
+	version := r.versionName()
+	if version == "" {
+		return methods
+	}
+	served := make(map[string]*route, len(methods))
+	for method, rt := range methods {
+		if rt.group.versionName() == version {
+			served[method] = rt
+		}
+	}
+	return served
+}
+
+// DeprecatedSince marks the route deprecated since date. Responses carry
+// the Deprecation header and, when link is set, a Link to the deprecation
+// notice.
+func DeprecatedSince(date time.Time, link string) RouteOption {
+	return func(rt *route) {
+		rt.deprecated = true
+		rt.deprecatedSince = date
+		rt.deprecationLink = link
+	}
+}
+
+// Sunset announces when the route stops being served with the Sunset header
+// and the x-sunset spec extension.
+func Sunset(date time.Time) RouteOption {
+	return func(rt *route) {
+		rt.sunset = date
+	}
+}
+
+// DeprecatedUsage is the traffic of a deprecated route.
+type DeprecatedUsage struct {
+	Method  string
+	Path    string
+	Version string
+	Sunset  time.Time
+	// Requests counts the requests since the router was built; LastRequest
+	// is zero when there were none.
+	Requests    uint64
+	LastRequest time.Time
+}
+
+// announceDeprecation sets the deprecation headers of a deprecated route and
+// counts its traffic.
+func (r *Router) announceDeprecation(c *gin.Context, rt *route) {
+	if !rt.deprecated && rt.sunset.IsZero() {
+		return
+	}
+	header := c.Writer.Header()
+	switch {
+	case !rt.deprecatedSince.IsZero():
+		header.Set("Deprecation", "@"+strconv.FormatInt(rt.deprecatedSince.Unix(), 10))
+	case rt.deprecated:
+		header.Set("Deprecation", "true")
+	}
+	if rt.deprecationLink != "" {
+		header.Add("Link", "<"+rt.deprecationLink+`>; rel="deprecation"`)
+	}
+	if !rt.sunset.IsZero() {
+		header.Set("Sunset", rt.sunset.UTC().Format(http.TimeFormat))
+	}
+
+	r.versions.mu.Lock()
+	defer r.versions.mu.Unlock()
+	usage := r.versions.usage[rt]
+	if usage == nil {
+		usage = deprecatedUsage(rt)
+		r.versions.usage[rt] = usage
+	}
+	usage.Requests++
+	usage.LastRequest = time.Now()
+}
+
+func deprecatedUsage(rt *route) *DeprecatedUsage {
+	return &DeprecatedUsage{
+		Method:  rt.method,
+		Path:    rt.path,
+		Version: rt.group.versionName(),
+		Sunset:  rt.sunset,
+	}
+}
+
+// DeprecatedTraffic reports the traffic of every deprecated route, including
+// those no longer called and safe to remove, by path and method.
+func (r *Router) DeprecatedTraffic() []DeprecatedUsage {
+	r.versions.mu.Lock()
+	defer r.versions.mu.Unlock()
+	var report []DeprecatedUsage
+	for _, methods := range r.routesMap {
+		for _, rt := range methods {
+			if !rt.deprecated && rt.sunset.IsZero() {
+				continue
+			}
+			if usage := r.versions.usage[rt]; usage != nil {
+				report = append(report, *usage)
+			} else {
+				report = append(report, *deprecatedUsage(rt))
+			}
+		}
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Path != report[j].Path {
+			return report[i].Path < report[j].Path
+		}
+		return report[i].Method < report[j].Method
+	})
+	return report
+}
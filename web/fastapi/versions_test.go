@@ -0,0 +1,112 @@
+package fastapi_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"testing"
+	"web/fastapi"
+)
+
+type release struct {
+	Version string `json:"version"`
+}
+
+func versionedRouter() (r, v1, v2 *fastapi.Router) {
+	r = fastapi.NewRouter()
+	v1 = r.Version("v1")
+	v2 = r.Version("v2")
+	for _, version := range []*fastapi.Router{v1, v2} {
+		name := map[*fastapi.Router]string{v1: "v1", v2: "v2"}[version]
+		version.Handle(http.MethodGet, "/release", func(c *gin.Context, in struct{}) (release, error) {
+			return release{Version: name}, nil
+		})
+	}
+	v2.Group("items").Handle(http.MethodGet, "/", func(c *gin.Context, in struct{}) (release, error) {
+		return release{Version: "v2"}, nil
+	})
+	r.Handle(http.MethodGet, "/health", func(c *gin.Context, in struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	})
+	return r, v1, v2
+}
+
+func TestVersions(t *testing.T) {
+	r, v1, v2 := versionedRouter()
+	items := v2.Group("items")
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		target  string
+		status  int
+		version string
+	}{
+		{"root serves v1", r.GinHandler, "/v1/release", http.StatusOK, "v1"},
+		{"root serves v2", r.GinHandler, "/v2/release", http.StatusOK, "v2"},
+		{"root serves unversioned routes", r.GinHandler, "/health", http.StatusOK, ""},
+		{"v1 serves its routes", v1.GinHandler, "/v1/release", http.StatusOK, "v1"},
+		{"v1 does not serve v2", v1.GinHandler, "/v2/release", http.StatusNotFound, ""},
+		{"v1 does not serve v2 groups", v1.GinHandler, "/v2/items", http.StatusNotFound, ""},
+		{"v1 does not serve unversioned routes", v1.GinHandler, "/health", http.StatusNotFound, ""},
+		{"v2 does not serve v1", v2.GinHandler, "/v1/release", http.StatusNotFound, ""},
+		{"v2 groups serve their version", items.GinHandler, "/v2/release", http.StatusOK, "v2"},
+		{"v2 groups do not serve v1", items.GinHandler, "/v1/release", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(test.handler, http.MethodGet, test.target, "", nil)
+			expectStatus(t, recorder, test.status)
+			if test.version != "" {
+				var out release
+				decode(t, recorder, &out)
+				if out.Version != test.version {
+					t.Errorf("served by %s, want %s", out.Version, test.version)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionHeader(t *testing.T) {
+	r, v1, _ := versionedRouter()
+	r.VersionHeader("API-Version", "v1")
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		header  string
+		status  int
+		version string
+	}{
+		{"fallback", r.GinHandler, "", http.StatusOK, "v1"},
+		{"header", r.GinHandler, "v2", http.StatusOK, "v2"},
+		{"unknown version", r.GinHandler, "v3", http.StatusBadRequest, ""},
+		{"v1 does not serve v2 by header", v1.GinHandler, "v2", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := http.Header{}
+			if test.header != "" {
+				header.Set("API-Version", test.header)
+			}
+			recorder := serve(test.handler, http.MethodGet, "/release", "", header)
+			expectStatus(t, recorder, test.status)
+			if test.version != "" {
+				var out release
+				decode(t, recorder, &out)
+				if out.Version != test.version {
+					t.Errorf("served by %s, want %s", out.Version, test.version)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionSpecs(t *testing.T) {
+	r, v1, _ := versionedRouter()
+	if paths := r.EmitOpenAPIDefinition().Paths.Paths; len(paths) != 4 {
+		t.Errorf("root documents %d paths, want 4", len(paths))
+	}
+	paths := v1.EmitOpenAPIDefinition().Paths.Paths
+	if _, present := paths["/v1/release"]; !present || len(paths) != 1 {
+		t.Errorf("v1 documents %d paths, want /v1/release only", len(paths))
+	}
+}
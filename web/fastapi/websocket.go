@@ -0,0 +1,178 @@
+package fastapi
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	openapi "github.com/go-openapi/spec"
+	"github.com/gorilla/websocket"
+	"log"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Conn is a WebSocket whose inbound messages are JSON-decoded into In and
+// outbound messages JSON-encoded from Out.
+type Conn[In, Out any] struct {
+	ws      *websocket.Conn
+	writing sync.Mutex
+}
+
+// Receive reads the next message. Messages that are not valid JSON or fail
+// In's validate tags are answered with a *ValidationError, leaving the
+// connection open.
+func (c *Conn[In, Out]) Receive() (In, error) {
+	var message In
+	_, data, err := c.ws.ReadMessage()
+	if err != nil {
+		return message, err
+	}
+	verr := &ValidationError{}
+	if err := json.Unmarshal(data, &message); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			verr.add("message", typeErr.Field, "expected "+typeErr.Type.String()+", got "+typeErr.Value)
+		} else {
+			verr.add("message", "", err.Error())
+		}
+		return message, verr
+	}
+	var raw interface{}
+	json.Unmarshal(data, &raw)
+	validateValue(verr, "message", "", reflect.ValueOf(&message).Elem(), constraints{}, raw)
+	if len(verr.Errors) > 0 {
+		return message, verr
+	}
+	return message, nil
+}
+
+// Send writes a message. It is safe to call from several goroutines.
+func (c *Conn[In, Out]) Send(message Out) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	c.writing.Lock()
+	defer c.writing.Unlock()
+	return c.ws.WriteMessage(websocket.TextMessage, data)
+}
+
+// Raw returns the underlying connection, for subprotocols or binary
+// messages.
+func (c *Conn[In, Out]) Raw() *websocket.Conn {
+	return c.ws
+}
+
+// WebSocketHandlerFunc serves one connection. Params is bound from the
+// upgrade request like any input. Returning closes the connection, normally
+// when err is nil and with an internal error status otherwise.
+type WebSocketHandlerFunc[Params, In, Out any] func(c *gin.Context, params Params, conn *Conn[In, Out]) error
+
+// WebSocket registers a GET route upgrading to a WebSocket once the
+// router's security, middleware and binding have accepted the request.
+// Requests from other origins are refused unless CheckOrigin allows them.
+func WebSocket[Params, In, Out any](r *Router, path string, handler WebSocketHandlerFunc[Params, In, Out], opts ...RouteOption) {
+	rt := &route{
+		method:     http.MethodGet,
+		path:       path,
+		inputType:  reflect.TypeOf((*Params)(nil)).Elem(),
+		outputType: webSocketType,
+		inbound:    reflect.TypeOf((*In)(nil)).Elem(),
+		outbound:   reflect.TypeOf((*Out)(nil)).Elem(),
+		status:     http.StatusSwitchingProtocols,
+		heartbeat:  defaultHeartbeat,
+	}
+	rt.invoke = func(c *gin.Context, inputPtr interface{}) (interface{}, error) {
+		if !websocket.IsWebSocketUpgrade(c.Request) {
+			c.Header("Upgrade", "websocket")
+			return nil, NewError(http.StatusUpgradeRequired, "upgrade_required", "WebSocket upgrade required")
+		}
+		params := *inputPtr.(*Params)
+		return webSocketUpgrade{serve: func(ws *websocket.Conn) error {
+			return handler(c, params, &Conn[In, Out]{ws: ws})
+		}, upgrader: websocket.Upgrader{CheckOrigin: rt.checkOrigin}, heartbeat: rt.heartbeat}, nil
+	}
+	r.addRoute(rt, opts)
+}
+
+// CheckOrigin decides which origins may open the route's WebSocket. By
+// default only the request's own host may.
+func CheckOrigin(check func(r *http.Request) bool) RouteOption {
+	return func(rt *route) {
+		rt.checkOrigin = check
+	}
+}
+
+type webSocketUpgrade struct {
+	serve     func(ws *websocket.Conn) error
+	upgrader  websocket.Upgrader
+	heartbeat time.Duration
+}
+
+var webSocketType = reflect.TypeOf(webSocketUpgrade{})
+
+func (u webSocketUpgrade) write(c *gin.Context, _ int) error {
+	ws, err := u.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader already answered the request.
+		log.Printf("fastapi: websocket upgrade failed: %v", err)
+		return nil
+	}
+	defer ws.Close()
+
+	if u.heartbeat > 0 {
+		ws.SetReadDeadline(time.Now().Add(2 * u.heartbeat))
+		ws.SetPongHandler(func(string) error {
+			return ws.SetReadDeadline(time.Now().Add(2 * u.heartbeat))
+		})
+		stop := make(chan struct{})
+		defer close(stop)
+		go keepAlive(ws, u.heartbeat, stop)
+	}
+
+	err = u.serve(ws)
+	code, reason := websocket.CloseNormalClosure, ""
+	var closeErr *websocket.CloseError
+	switch {
+	case err == nil:
+	case errors.As(err, &closeErr):
+		// The client closed the connection.
+		return nil
+	default:
+		log.Printf("fastapi: websocket handler failed: %v", err)
+		code, reason = websocket.CloseInternalServerErr, http.StatusText(http.StatusInternalServerError)
+	}
+	ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	return nil
+}
+
+// keepAlive pings the client every interval. Clients failing to answer
+// within two intervals make Receive fail.
+func keepAlive(ws *websocket.Conn, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// addWebSocketSpec documents the messages of a WebSocket route under the
+// x-websocket extension, from the client's point of view.
+func addWebSocketSpec(op *openapi.Operation, rt *route, gen *schemaGenerator) {
+	if rt.inbound == nil {
+		return
+	}
+	op.AddExtension("x-websocket", map[string]openapi.Schema{
+		"send":    gen.schemaFor(rt.inbound),
+		"receive": gen.schemaFor(rt.outbound),
+	})
+}
@@ -0,0 +1,197 @@
+package fastapi_test
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"web/fastapi"
+)
+
+type chatInput struct {
+	Room string `path:"room"`
+}
+
+type chatMessage struct {
+	Text string `json:"text" validate:"minlen=1"`
+}
+
+type chatReply struct {
+	Room  string `json:"room,omitempty"`
+	Text  string `json:"text,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func chatRouter() *fastapi.Router {
+	r := fastapi.NewRouter()
+	chat := func(c *gin.Context, in chatInput, conn *fastapi.Conn[chatMessage, chatReply]) error {
+		for {
+			message, err := conn.Receive()
+			var verr *fastapi.ValidationError
+			switch {
+			case errors.As(err, &verr):
+				if err := conn.Send(chatReply{Error: verr.Error()}); err != nil {
+					return err
+				}
+				continue
+			case err != nil:
+				return err
+			case message.Text == "bye":
+				return nil
+			case message.Text == "fail":
+				return errors.New("chat failed")
+			}
+			if err := conn.Send(chatReply{Room: in.Room, Text: strings.ToUpper(message.Text)}); err != nil {
+				return err
+			}
+		}
+	}
+	fastapi.WebSocket(r, "/rooms/{room}", chat, fastapi.Heartbeat(100*time.Millisecond))
+	fastapi.WebSocket(r, "/lobby/{room}", chat, fastapi.CheckOrigin(func(r *http.Request) bool {
+		return r.Header.Get("Origin") == "https://app.test"
+	}))
+	return r
+}
+
+func dial(t *testing.T, server *httptest.Server, path string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	ws, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+path, header)
+	if err == nil {
+		t.Cleanup(func() { ws.Close() })
+	}
+	return ws, resp, err
+}
+
+func exchange(t *testing.T, ws *websocket.Conn, message string) chatReply {
+	t.Helper()
+	if err := ws.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		t.Fatal(err)
+	}
+	var reply chatReply
+	if err := ws.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+	return reply
+}
+
+// closeCode reads until the server closes the connection and returns the
+// close status.
+func closeCode(t *testing.T, ws *websocket.Conn) int {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, _, err := ws.ReadMessage()
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) {
+			return closeErr.Code
+		}
+		if err != nil {
+			t.Fatalf("read %v, want a close message", err)
+		}
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	server := listen(t, chatRouter())
+	ws, _, err := dial(t, server, "/rooms/go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply := exchange(t, ws, `{"text": "hi"}`); reply != (chatReply{Room: "go", Text: "HI"}) {
+		t.Errorf("replied %+v, want HI in go", reply)
+	}
+	if reply := exchange(t, ws, `{"text": ""}`); !strings.Contains(reply.Error, "validation") {
+		t.Errorf("replied %+v, want a validation error", reply)
+	}
+	if reply := exchange(t, ws, `{"text": 1}`); reply.Error == "" {
+		t.Errorf("replied %+v, want a decoding error", reply)
+	}
+	if reply := exchange(t, ws, `{"text": "still open"}`); reply.Text != "STILL OPEN" {
+		t.Errorf("replied %+v after invalid messages", reply)
+	}
+	ws.WriteMessage(websocket.TextMessage, []byte(`{"text": "bye"}`))
+	if code := closeCode(t, ws); code != websocket.CloseNormalClosure {
+		t.Errorf("closed with %d, want %d", code, websocket.CloseNormalClosure)
+	}
+
+	ws, _, err = dial(t, server, "/rooms/go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.WriteMessage(websocket.TextMessage, []byte(`{"text": "fail"}`))
+	if code := closeCode(t, ws); code != websocket.CloseInternalServerErr {
+		t.Errorf("closed with %d, want %d", code, websocket.CloseInternalServerErr)
+	}
+}
+
+func TestWebSocketHeartbeat(t *testing.T) {
+	ws, _, err := dial(t, listen(t, chatRouter()), "/rooms/go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinged := make(chan struct{}, 1)
+	ws.SetPingHandler(func(data string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	go ws.ReadMessage()
+	select {
+	case <-pinged:
+	case <-time.After(2 * time.Second):
+		t.Error("the server did not ping")
+	}
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	server := listen(t, chatRouter())
+	tests := []struct {
+		name   string
+		path   string
+		origin string
+		status int
+	}{
+		{"same origin", "/rooms/go", server.URL, http.StatusSwitchingProtocols},
+		{"other origin", "/rooms/go", "https://evil.test", http.StatusForbidden},
+		{"allowed origin", "/lobby/go", "https://app.test", http.StatusSwitchingProtocols},
+		{"origin not allowed", "/lobby/go", server.URL, http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, resp, _ := dial(t, server, test.path, http.Header{"Origin": {test.origin}})
+			if resp == nil || resp.StatusCode != test.status {
+				t.Errorf("handshake answered %v, want %d", resp, test.status)
+			}
+		})
+	}
+}
+
+func TestWebSocketUpgradeRequired(t *testing.T) {
+	recorder := serve(chatRouter().GinHandler, http.MethodGet, "/rooms/go", "", nil)
+	expectStatus(t, recorder, http.StatusUpgradeRequired)
+	if upgrade := recorder.Header().Get("Upgrade"); upgrade != "websocket" {
+		t.Errorf("Upgrade %q, want websocket", upgrade)
+	}
+	if code := problem(t, recorder).Code; code != "upgrade_required" {
+		t.Errorf("code %q, want upgrade_required", code)
+	}
+}
+
+func TestWebSocketSpec(t *testing.T) {
+	op := chatRouter().EmitOpenAPI31Definition().Paths["/rooms/{room}"].Get
+	if op == nil || op.Responses["101"].Description == "" {
+		t.Fatalf("operation %+v, want a 101 response", op)
+	}
+	send, receive := op.WebSocket["send"], op.WebSocket["receive"]
+	if send := send.Ref.String(); send != "#/components/schemas/fastapi_test.chatMessage" {
+		t.Errorf("send %q, want the chatMessage schema", send)
+	}
+	if receive := receive.Ref.String(); receive != "#/components/schemas/fastapi_test.chatReply" {
+		t.Errorf("receive %q, want the chatReply schema", receive)
+	}
+}
@@ -1,8 +1,6 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 
@@ -33,16 +31,14 @@ func main() {
 	}
 
 	myRouter := fastapi.NewRouter()
-	myRouter.AddCall("/echo", EchoHandler)
-
-	swagger := myRouter.EmitOpenAPIDefinition()
-	swagger.Info.Title = "My awesome API"
-	prefix, indent := "", "    "
-	jsonBytes, _ := json.MarshalIndent(swagger, prefix, indent)
-	fmt.Println(string(jsonBytes))
+	fastapi.Post(myRouter, "/echo", EchoHandler)
 
 	router := gin.Default()
 	router.GET("/path/:name", handler)
-	router.POST("/api/*path", myRouter.GinHandler)
+	router.Any("/api/*path", myRouter.GinHandler)
+	myRouter.MountDocs(router, fastapi.DocsConfig{
+		Title:   "My awesome API",
+		Servers: []fastapi.OpenAPIServer{{URL: "/api"}},
+	})
 	router.Run("0.0.0.0:8888")
 }